@@ -3,17 +3,27 @@ package blueprint
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg" // Register the decoders of the mask images.
+	_ "image/png"
 	"io/ioutil"
 	"math"
+	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	rand7i "github.com/7i/rand"
 
 	"github.com/karlek/wasabi/coloring"
+	"github.com/karlek/wasabi/expr"
 	"github.com/karlek/wasabi/fractal"
 	"github.com/karlek/wasabi/iro"
 	"github.com/karlek/wasabi/mandel"
 	"github.com/karlek/wasabi/plot"
+	"github.com/karlek/wasabi/prec"
 	"github.com/karlek/wasabi/render"
 
 	"github.com/sirupsen/logrus"
@@ -22,10 +32,17 @@ import (
 // Blueprint contains the settings and options needed to render a fractal.
 type Blueprint struct {
 	Iterations float64 // Number of iterations.
-	Bailout    float64 // Squared radius of the function domain. Most commonly set to 4, but it's important for planes other than Zrzi.
+	Bailout    float64 // Squared radius of the function domain. Most commonly set to 4, but it's important for planes other than Zrzi. Defaults to the recommended bailout of the formula.
+	Epsilon    float64 // Tolerance of the cycle detection. Zero only detects exact cycles, while e.g. 1e-10 stops near-periodic orbits early.
 	Tries      float64 // The number of orbit attempts calculated by: tries * (width * height)
 
-	Coloring string // Coloring method for the orbits.
+	Coloring string // Coloring method for the orbits: iteration, modulo, vector, orbit, path or nebula.
+
+	// Iteration limits and thresholds of the red, green and blue channels of the
+	// nebula coloring, which are filled in the same pass. The iterations are
+	// raised to the largest limit.
+	NebulaIterations [3]float64
+	NebulaThreshold  [3]float64
 
 	DrawPath    bool  // Draw the path between points in the orbit.
 	PathPoints  int64 // The number of intermediate points to use for interpolation.
@@ -42,31 +59,91 @@ type Blueprint struct {
 	Imag      float64 // Offset on the imaginary-value axis.
 	Real      float64 // Offset on the real-value axis.
 	Zoom      float64 // Zoom factor.
+	Roll      float64 // Rotation angle in radians of the camera around its center.
+	Aspect    float64 // Width of the pixels relative to their height, defaults to 1.
 	Seed      int64   // Random seed.
 	Threshold float64 // Minimum orbit length to be registered.
+	Filter    *Filter // Filter of the registered orbits by their properties.
+	PrePass   int     // Resolution of the escape-time pre-pass grid which rejects approximately interior and trivially escaping starting points, zero disables it. Requires the mandelbrot function and z to be given by c.
+
+	// Deep zooms beyond the precision of float64 iterate the orbits in a higher
+	// precision, which mandelbrot, burningship and tricorn support.
+	Precision                string // Precision of the iterations and the camera: float64, double-double or big.
+	PrecisionBits            uint   // Bits of the big precision, defaults to enough bits for the zoom.
+	PreciseReal, PreciseImag string // Offset of the camera as decimals in full precision, which replace real and imag.
+
+	TimeBudget float64 // Stop sampling after this number of seconds, zero disables the budget.
+	Noise      float64 // Stop sampling when the relative change of the histograms between rounds falls below, zero disables the budget.
 
-	// Coefficients multiplied to the imaginary and real parts in the complex function.
+	Checkpoint         string  // Path of the checkpoint file to periodically save the render progress to. Frames are checkpointed to the path suffixed with the frame number.
+	CheckpointInterval float64 // Minimum number of seconds between checkpoints.
+
+	// Coefficients multiplied to the imaginary and real parts in the complex
+	// function. The real coefficient defaults to 1.
 	ImagCoefficient float64
-	RealCoefficient float64
+	RealCoefficient *float64
 
 	Function string  // Normalization function for scaling the brightness of the pixels.
 	Factor   float64 // Factor is used by the functions in various ways.
 	Exposure float64 // Exposure is a scaling factor applied after the normalization function has been applied.
 
-	RegisterMode string // How the fractal will capture orbits. The different modes are: anti, primitive and escapes.
+	// Anti-aliasing of the orbit points.
+	Splat         string  // Kernel which distributes the points to the neighbouring cells of the histograms: nearest, bilinear, tent or gaussian.
+	SplatRadius   float64 // Radius in cells of the tent kernel and standard deviation of the gaussian kernel.
+	Supersampling int     // Cells of the histograms per pixel along each axis, which are downsampled when plotted.
+
+	RegisterMode string // How the fractal will capture orbits. The different modes are: anti, primitive, escapes and convergent, which registers the orbits of the root-finding formulas newton and nova.
+
+	ComplexFunction string       // The complex function we shall explore: a formula listed by wasabi -list-functions or an expression of z, c and coef such as "z^3 + c*sin(z)".
+	Polynomial      [][2]float64 // Coefficients (real, imaginary) of the polynomial of the newton and nova formulas in order of increasing degree, e.g. [[-1, 0], [0, 0], [0, 0], [1, 0]] is z^3 - 1.
 
-	ComplexFunction string // The complex function we shall explore.
+	Plane      string              // Chose which capital plane we will plot: Crci, Crzi, Zici, Zicr, Zrci, Zrcr, Zrzi.
+	Projection *fractal.Projection // Projection matrix of (Zr, Zi, Cr, Ci) onto the image, which overrides the plane. E.g. [[1,0,0,0],[0,1,0,0]] is Zrzi.
 
-	Plane string // Chose which capital plane we will plot: Crci, Crzi, Zici, Zrci, Zrcr, Zrzi.
+	BaseColor iro.RGBA     // The background color.
+	Gradient  []iro.RGBA   // The color gradient used by the coloring methods.
+	Range     []float64    // The interpolation points for the gradient.
+	Basins    [][]iro.RGBA // Color gradients of the basins of the roots of the newton and nova formulas, with the interpolation points of the range. The basin after the roots colors the orbits converging to attracting cycles. Requires the convergent registrer.
+
+	ZUpdate string // Chose how we shall update Z: random, halton, r2, sobol, origo, critical or a1-a6.
+	CUpdate string // Chose how we shall update C: random, halton, r2, sobol, origo, critical, julia or a1-a6.
+	Sampler string // Chose how we sample the starting points: uniform, metropolis or adaptive.
+
+	// Julia buddhabrots fix c to a constant with the CUpdate julia, while z is
+	// sampled. The constant is swept linearly to the end point over the frames.
+	JuliaReal, JuliaImag       float64 // The constant c.
+	JuliaEndReal, JuliaEndImag float64 // The constant c of the last frame.
+	Frames                     int     // Number of frames of the sweep, rendered to the output filename suffixed with the frame number.
+
+	// Restrict the random or quasi-random starting points of z and c to a
+	// domain. Sampling the whole rectangle [-2, 2) is the default.
+	ZDomain *Domain
+	CDomain *Domain
+
+	Rotation fractal.Rotation // Angles in radians of the 4D rotation of (Zr, Zi, Cr, Ci) in the six planes: ZrZi, ZrCr, ZrCi, ZiCr, ZiCi and CrCi.
+	Theta    float64          // Rotation angle of the ZrCr plane, added to the rotation.
+}
 
-	BaseColor iro.RGBA   // The background color.
-	Gradient  []iro.RGBA // The color gradient used by the coloring methods.
-	Range     []float64  // The interpolation points for the gradient.
+// Filter selects the registered orbits, e.g. only the period 3 components of
+// an anti-buddhabrot. The zero value of an option disables it.
+type Filter struct {
+	MinLength, MaxLength float64 // Window of orbit lengths.
+	MinAngle, MaxAngle   float64 // Range in radians of the argument of the last point of the orbit, e.g. the point of escape. The range wraps around if the minimum is larger than the maximum.
+	MinRadius, MaxRadius float64 // Band of the modulus of the last point of the orbit.
+	Period               int64   // Period of the cycle the orbit converged to, for the anti-buddhabrot.
+	Trap                 *Domain // Shape which the orbit must enter.
+}
 
-	ZUpdate string // Chose how we shall update Z.
-	CUpdate string // Chose how we shall update C.
+// Domain describes a region of the complex plane which starting points are
+// sampled from, or which orbits are trapped by.
+type Domain struct {
+	Shape string // The shape of the domain: rectangle, disc, annulus or mask.
 
-	Theta float64 // Rotation angle. Experimental option since it demands matrix rotation which slows down the renders considerably on CPU based renders.
+	Real, Imag    float64 // Center of the domain.
+	Width, Height float64 // Size of rectangles and masks.
+	Radius        float64 // Outer radius of discs and annuli. Defaults to the square root of the bailout.
+	Inner         float64 // Inner radius of annuli. An annulus without radii surrounds the mandelbrot boundary.
+	Mask          string  // Path to the image of a mask, whose white pixels are sampled.
 }
 
 // Parse opens and parses a blueprint json file.
@@ -76,8 +153,35 @@ func Parse(filename string) (blue *Blueprint, err error) {
 		return blue, err
 	}
 	blue = new(Blueprint)
-	err = json.Unmarshal(buf, blue)
-	return blue, err
+	if err := json.Unmarshal(buf, blue); err != nil {
+		return blue, err
+	}
+	return blue, blue.validate()
+}
+
+// validate returns an error describing the first invalid combination of
+// options of the blueprint.
+func (b *Blueprint) validate() error {
+	if b.Frames > 1 && strings.ToLower(b.CUpdate) != "julia" {
+		return errors.New("frames sweep the julia constant, which requires the c update julia")
+	}
+	if len(b.Basins) > 0 {
+		switch strings.ToLower(b.RegisterMode) {
+		case "convergent", "roots":
+		default:
+			return fmt.Errorf("basins color the roots of the convergent registrer, not: %s", b.RegisterMode)
+		}
+	}
+	if b.PrePass > 0 {
+		if !strings.EqualFold(b.ComplexFunction, "mandelbrot") {
+			return fmt.Errorf("the pre-pass approximates the mandelbrot set, not the complex function %q", b.ComplexFunction)
+		}
+		switch strings.ToLower(b.ZUpdate) {
+		case "random", "halton", "r2", "sobol":
+			return fmt.Errorf("the pre-pass requires z to be given by c, not: %s", b.ZUpdate)
+		}
+	}
+	return nil
 }
 
 // Render creates a render object for the blueprint.
@@ -91,10 +195,34 @@ func (b *Blueprint) Render() *render.Render {
 	)
 }
 
+// FramePath returns the path of the i:th frame of a sweep, e.g. the output
+// filename or checkpoint of the frame.
+func FramePath(path string, i int) string {
+	return fmt.Sprintf("%s-%04d", path, i)
+}
+
+// Frame returns the blueprint of the i:th frame of the sweep of the julia
+// constant.
+func (b *Blueprint) Frame(i int) *Blueprint {
+	frame := *b
+	if b.Frames > 1 {
+		t := float64(i) / float64(b.Frames-1)
+		frame.JuliaReal = b.JuliaReal + t*(b.JuliaEndReal-b.JuliaReal)
+		frame.JuliaImag = b.JuliaImag + t*(b.JuliaEndImag-b.JuliaImag)
+		if b.Checkpoint != "" {
+			frame.Checkpoint = FramePath(b.Checkpoint, i)
+		}
+	}
+	return &frame
+}
+
 // Fractal creates a fractal object for the blueprint.
 func (b *Blueprint) Fractal() *fractal.Fractal {
 	// Coefficient multiplied inside the complex function we are investigating.
-	coefficient := complex(b.RealCoefficient, b.ImagCoefficient)
+	coefficient := complex(1, b.ImagCoefficient)
+	if b.RealCoefficient != nil {
+		coefficient = complex(*b.RealCoefficient, b.ImagCoefficient)
+	}
 
 	// Offset the fractal rendering.
 	offset := complex(b.Real, b.Imag)
@@ -102,36 +230,162 @@ func (b *Blueprint) Fractal() *fractal.Fractal {
 	// Our way of registering orbits. Either we register the orbits that either converges, diverges or both.
 	registerMode := parseRegistrer(b.RegisterMode)
 
-	// Get the complex function to find orbits with.
-	f := parseComplexFunctionFlag(b.ComplexFunction)
+	// Get the complex function to find orbits with, and the options it is best
+	// explored with.
+	formula := parseComplexFunctionFlag(b.ComplexFunction)
+	if len(b.Polynomial) > 0 {
+		if formula.Polynomial == nil {
+			logrus.Fatalln("the complex function has no polynomial:", b.ComplexFunction)
+		}
+		p := make(mandel.Polynomial, len(b.Polynomial))
+		for i, coef := range b.Polynomial {
+			p[i] = complex(coef[0], coef[1])
+		}
+		formula = formula.Polynomial(p)
+	}
+	bailout := b.Bailout
+	if bailout == 0 {
+		bailout = formula.Bailout
+	}
+	cdomain := formula.Domain
+	if b.CDomain != nil {
+		cdomain = parseDomain(b.CDomain, bailout)
+	}
+	var zdomain fractal.Domain
+	if b.ZDomain != nil {
+		zdomain = parseDomain(b.ZDomain, bailout)
+	}
+
+	julia := complex(b.JuliaReal, b.JuliaImag)
+	z := parseZandC(b.ZUpdate, zdomain, formula.Critical, julia)
+	c := parseZandC(b.CUpdate, cdomain, formula.Critical, julia)
+
+	var filter *fractal.Filter
+	if b.Filter != nil {
+		filter = &fractal.Filter{
+			MinLength: int64(b.Filter.MinLength),
+			MaxLength: int64(b.Filter.MaxLength),
+			MinAngle:  b.Filter.MinAngle,
+			MaxAngle:  b.Filter.MaxAngle,
+			MinRadius: b.Filter.MinRadius,
+			MaxRadius: b.Filter.MaxRadius,
+			Period:    b.Filter.Period,
+		}
+		if b.Filter.Trap != nil {
+			filter.Trap = parseDomain(b.Filter.Trap, bailout)
+		}
+	}
+
+	// The camera center in the precision tier is the origin of the orbits.
+	tier, err := prec.ParseTier(b.Precision)
+	if err != nil {
+		logrus.Fatalln(err)
+	}
+	var origin prec.Complex
+	bits := b.PrecisionBits
+	if tier != prec.Float64 {
+		if tier == prec.Big && bits == 0 {
+			bits = prec.ZoomBits(b.Zoom)
+		}
+		re, im := b.PreciseReal, b.PreciseImag
+		if re == "" {
+			re = strconv.FormatFloat(b.Real, 'g', -1, 64)
+		}
+		if im == "" {
+			im = strconv.FormatFloat(b.Imag, 'g', -1, 64)
+		}
+		off, err := prec.Parse(tier, re, im, bits)
+		if err != nil {
+			logrus.Fatalln("invalid offset:", err)
+		}
+		origin = prec.New(tier, 0, bits).Sub(off)
+	}
 
-	z := parseZandC(b.ZUpdate)
-	c := parseZandC(b.CUpdate)
+	rotation := b.Rotation
+	rotation.ZrCr += b.Theta
 
 	colors := iro.ToColors(b.Gradient)
 	method := coloring.NewColoring(b.BaseColor, parseModeFlag(b.Coloring), colors, b.Range)
+	for _, basin := range b.Basins {
+		method.AddBasin(iro.ToColors(basin), b.Range)
+	}
+
+	iterations := int64(b.Iterations)
+	var nebula *fractal.Nebula
+	if method.Mode() == coloring.Nebula {
+		nebula = new(fractal.Nebula)
+		for i := range nebula.Iterations {
+			nebula.Iterations[i] = int64(b.NebulaIterations[i])
+			nebula.Threshold[i] = int64(b.NebulaThreshold[i])
+			if nebula.Iterations[i] > iterations {
+				iterations = nebula.Iterations[i]
+			}
+		}
+	}
+
+	var splat *fractal.Splat
+	if kernel := parseKernel(b.Splat); kernel != fractal.Nearest {
+		splat = &fractal.Splat{Kernel: kernel, Radius: b.SplatRadius}
+	}
 
 	// Fill our histogram bins of the orbits.
-	return fractal.New(
-		b.Width,
-		b.Height,
-		int64(b.Iterations),
-		method,
-		coefficient,
-		b.Bailout,
-		parsePlane(b.Plane),
-		f,
-		b.Zoom,
-		offset,
-		b.PlotImportance,
-		b.Seed,
-		b.PathPoints,
-		b.BezierLevel,
-		b.Tries,
-		registerMode,
-		b.Theta,
-		z, c,
-		int64(b.Threshold))
+	frac, err := fractal.FromConfig(fractal.Config{
+		Width:              b.Width,
+		Height:             b.Height,
+		Method:             method,
+		PlotImportance:     b.PlotImportance,
+		Iterations:         iterations,
+		Bailout:            bailout,
+		Plane:              parsePlane(b.Plane, b.Projection),
+		Func:               formula.Func,
+		Memory:             formula.Memory,
+		Register:           registerMode,
+		Coef:               coefficient,
+		Epsilon:            b.Epsilon,
+		Roots:              formula.Roots,
+		Precision:          tier,
+		Prec:               formula.Prec,
+		Bits:               bits,
+		Origin:             origin,
+		Zoom:               b.Zoom,
+		Offset:             offset,
+		Roll:               b.Roll,
+		Aspect:             b.Aspect,
+		Splat:              splat,
+		Supersampling:      b.Supersampling,
+		Tries:              b.Tries,
+		Seed:               b.Seed,
+		Threshold:          int64(b.Threshold),
+		Sampler:            parseSampler(b.Sampler),
+		Julia:              strings.ToLower(b.CUpdate) == "julia",
+		Filter:             filter,
+		TimeBudget:         time.Duration(b.TimeBudget * float64(time.Second)),
+		Noise:              b.Noise,
+		Checkpoint:         b.Checkpoint,
+		CheckpointInterval: time.Duration(b.CheckpointInterval * float64(time.Second)),
+		PathPoints:         b.PathPoints,
+		BezierLevel:        b.BezierLevel,
+		Nebula:             nebula,
+		Z:                  z,
+		C:                  c,
+		CDomain:            cdomain,
+		ZSource:            parseSource(b.ZUpdate, fractal.DimZ),
+		CSource:            parseSource(b.CUpdate, fractal.DimC),
+		Rotation:           rotation,
+	})
+	if err != nil {
+		logrus.Fatalln("invalid blueprint:", err)
+	}
+	if origin != nil {
+		// The center of the camera relative to the projected origin, which
+		// is zero unless the plane mixes z and c.
+		center := origin.Complex128()
+		frac.Camera.Center = center - frac.Project(center, center)
+	}
+	if b.PrePass > 0 {
+		frac.Grid = fractal.NewGrid(frac, b.PrePass)
+	}
+	return frac
 }
 
 // parseRegisterMode parses the _registerer_ string to a fractal orbit registrer.
@@ -144,6 +398,8 @@ func parseRegistrer(registrer string) mandel.Registrer {
 		return mandel.Primitive
 	case "escapes", "escape":
 		return mandel.Escaped
+	case "convergent", "roots":
+		return mandel.Convergent
 	default:
 		logrus.Fatalln("Unknown registrer:", registrer)
 	}
@@ -167,48 +423,38 @@ func parseFunctionFlag(f string) func(float64, float64) float64 {
 	return plot.Exp
 }
 
-// parsePlane parses the _plane string to a plane selection.
-func parsePlane(plane string) func(complex128, complex128) complex128 {
-	switch strings.ToLower(plane) {
-	case "zrzi":
-		// Original.
+// parsePlane parses the _plane string to a plane selection, or uses the
+// projection if given.
+func parsePlane(plane string, projection *fractal.Projection) func(complex128, complex128) complex128 {
+	if projection != nil {
+		return projection.Plane()
+	}
+	if plane == "" {
 		return fractal.Zrzi
-	case "zrcr":
-		// Pretty :D
-		return fractal.Zrcr
-	case "zrci":
-		// Pretty :D
-		return fractal.Zrci
-	case "crci":
-		// Mandelbrot perimiter.
-		return fractal.Crci
-	case "zicr":
-		// Pretty :D
-		return fractal.Zicr
-	case "zici":
-		// Pretty :D
-		return fractal.Zici
-	default:
+	}
+	f, ok := fractal.NamedPlane(plane)
+	if !ok {
 		logrus.Fatalln("invalid plane:", plane)
 	}
-	return fractal.Zrzi
+	return f
 }
 
-// parseComplexFunctionFlag parses the _function_ string to a complex function.
-func parseComplexFunctionFlag(function string) func(complex128, complex128, complex128) complex128 {
-	switch strings.ToLower(function) {
-	case "mandelbrot":
-		return mandel.Mandelbrot
-	case "burningship":
-		return mandel.BurningShip
-	case "b1":
-		return mandel.B1
-	case "b2":
-		return mandel.B2
-	default:
-		logrus.Fatalln("invalid complex function:", function)
+// parseComplexFunctionFlag parses the _function_ string to a formula of the
+// catalogue or compiles it as an expression.
+func parseComplexFunctionFlag(function string) mandel.Formula {
+	formula, err := mandel.LookupFormula(function)
+	if err == nil {
+		return formula
+	}
+	if !errors.Is(err, mandel.ErrUnknownFormula) {
+		logrus.Fatalln("invalid complex function:", err)
+	}
+	// Otherwise the function is an expression, e.g. "z^3 + c*sin(z)".
+	f, err := expr.Compile(function)
+	if err != nil {
+		logrus.Fatalln("invalid complex function:", err)
 	}
-	return mandel.Mandelbrot
+	return mandel.Formula{Name: function, Func: f, Bailout: 4}
 }
 
 // parseModeFlag parses the _mode_ string to a coloring function.
@@ -224,6 +470,8 @@ func parseModeFlag(mode string) coloring.Mode {
 		return coloring.OrbitLength
 	case "path":
 		return coloring.Path
+	case "nebula":
+		return coloring.Nebula
 	default:
 		logrus.Fatalln("invalid coloring function:", mode)
 	}
@@ -231,32 +479,39 @@ func parseModeFlag(mode string) coloring.Mode {
 }
 
 // parseZandC choses the sampling methods for our original points.
-func parseZandC(mode string) func(complex128, *rand7i.ComplexRNG) complex128 {
+func parseZandC(mode string, domain fractal.Domain, critical, julia complex128) func(complex128, fractal.Source) complex128 {
 	switch strings.ToLower(mode) {
-	case "random":
+	case "random", "halton", "r2", "sobol":
+		if domain != nil {
+			return fractal.InDomain(domain)
+		}
 		return fractal.RandomPoint
 	case "origo":
-		return func(_ complex128, _ *rand7i.ComplexRNG) complex128 { return complex(0, 0) }
+		return func(_ complex128, _ fractal.Source) complex128 { return complex(0, 0) }
+	case "critical":
+		return func(_ complex128, _ fractal.Source) complex128 { return critical }
+	case "julia":
+		return func(_ complex128, _ fractal.Source) complex128 { return julia }
 	case "a1":
-		return func(c complex128, _ *rand7i.ComplexRNG) complex128 { return complex(real(c), -imag(c)) }
+		return func(c complex128, _ fractal.Source) complex128 { return complex(real(c), -imag(c)) }
 	case "a2":
-		return func(c complex128, _ *rand7i.ComplexRNG) complex128 {
+		return func(c complex128, _ fractal.Source) complex128 {
 			return complex(math.Sin(real(c)), math.Sin(imag(c)))
 		}
 	case "a3":
-		return func(c complex128, _ *rand7i.ComplexRNG) complex128 {
+		return func(c complex128, _ fractal.Source) complex128 {
 			return complex(math.Abs(real(c)), math.Abs(imag(c)))
 		}
 	case "a4":
-		return func(c complex128, _ *rand7i.ComplexRNG) complex128 {
+		return func(c complex128, _ fractal.Source) complex128 {
 			return complex(real(c)/imag(c), real(c))
 		}
 	case "a5":
-		return func(c complex128, _ *rand7i.ComplexRNG) complex128 {
+		return func(c complex128, _ fractal.Source) complex128 {
 			return complex(real(c)*imag(c), -imag(c))
 		}
 	case "a6":
-		return func(c complex128, _ *rand7i.ComplexRNG) complex128 {
+		return func(c complex128, _ fractal.Source) complex128 {
 			return complex(-imag(c), -real(c))
 		}
 	default:
@@ -264,3 +519,88 @@ func parseZandC(mode string) func(complex128, *rand7i.ComplexRNG) complex128 {
 	}
 	return fractal.RandomPoint
 }
+
+// parseDomain parses the _domain_ to a sampling domain.
+func parseDomain(domain *Domain, bailout float64) fractal.Domain {
+	center := complex(domain.Real, domain.Imag)
+	size := complex(domain.Width, domain.Height) / 2
+	radius := domain.Radius
+	if radius == 0 {
+		radius = math.Sqrt(bailout)
+	}
+	switch strings.ToLower(domain.Shape) {
+	case "rectangle", "rect":
+		return fractal.Rectangle{Min: center - size, Max: center + size}
+	case "disc", "circle":
+		return fractal.Disc{Center: center, Radius: radius}
+	case "annulus":
+		if domain.Radius == 0 && domain.Inner == 0 {
+			return fractal.Annulus{Center: -0.5, Inner: 0.25, Outer: 1.5}
+		}
+		return fractal.Annulus{Center: center, Inner: domain.Inner, Outer: radius}
+	case "mask":
+		file, err := os.Open(domain.Mask)
+		if err != nil {
+			logrus.Fatalln(err)
+		}
+		defer file.Close()
+		img, _, err := image.Decode(file)
+		if err != nil {
+			logrus.Fatalln("invalid mask:", err)
+		}
+		mask, err := fractal.NewMask(img, center-size, center+size)
+		if err != nil {
+			logrus.Fatalln("invalid mask:", err)
+		}
+		return mask
+	default:
+		logrus.Fatalln("invalid domain:", domain.Shape)
+	}
+	return fractal.Rectangle{Min: complex(-2, -2), Max: complex(2, 2)}
+}
+
+// parseSampler parses the _sampler_ string to a sampling strategy.
+func parseSampler(sampler string) fractal.Sampler {
+	switch strings.ToLower(sampler) {
+	case "", "uniform":
+		return fractal.Uniform
+	case "metropolis", "mh":
+		return fractal.Metropolis
+	case "adaptive", "importance":
+		return fractal.Adaptive
+	default:
+		logrus.Fatalln("invalid sampler:", sampler)
+	}
+	return fractal.Uniform
+}
+
+// parseKernel parses the name of a splat kernel.
+func parseKernel(kernel string) fractal.Kernel {
+	switch strings.ToLower(kernel) {
+	case "", "nearest":
+		return fractal.Nearest
+	case "bilinear":
+		return fractal.Bilinear
+	case "tent":
+		return fractal.Tent
+	case "gaussian":
+		return fractal.Gaussian
+	default:
+		logrus.Fatalln("invalid splat kernel:", kernel)
+	}
+	return fractal.Nearest
+}
+
+// parseSource choses the sequence of points which z or c are sampled from,
+// from the two dimensions starting at dim of the low-discrepancy sequences.
+func parseSource(mode string, dim int) func(*rand7i.ComplexRNG) fractal.Source {
+	switch strings.ToLower(mode) {
+	case "halton":
+		return fractal.NewHalton(dim)
+	case "r2":
+		return fractal.NewR2(dim)
+	case "sobol":
+		return fractal.NewSobol(dim)
+	}
+	return fractal.NewRandom
+}
@@ -0,0 +1,75 @@
+package blueprint
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/karlek/wasabi/fractal"
+)
+
+func TestParsePlane(t *testing.T) {
+	z, c := complex(1, 2), complex(3, 4)
+	for _, name := range []string{"Crci", "Crzi", "Zici", "Zicr", "Zrci", "Zrcr", "Zrzi"} {
+		want, _ := fractal.NamedPlane(name)
+		if got := parsePlane(name, nil); got(z, c) != want(z, c) {
+			t.Errorf("%s: expected %v, got %v", name, want(z, c), got(z, c))
+		}
+	}
+	// The projection overrides the plane.
+	p := &fractal.Projection{{0, 0, 1, 0}, {0, 1, 0, 0}}
+	if got := parsePlane("zrzi", p); got(z, c) != fractal.Crzi(z, c) {
+		t.Errorf("expected the projection %v, got %v", fractal.Crzi(z, c), got(z, c))
+	}
+}
+
+func TestValidate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wasabi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		json string
+		ok   bool
+	}{
+		{`{"frames": 10, "cUpdate": "julia"}`, true},
+		{`{"frames": 1}`, true},
+		// The frames of a sweep without the julia constant would be identical.
+		{`{"frames": 10, "cUpdate": "random"}`, false},
+		{`{"frames": 10}`, false},
+		// Only the convergent registrer finds the roots of the basins.
+		{`{"registerMode": "convergent", "basins": [[{"R": 1, "A": 1}]]}`, true},
+		{`{"registerMode": "escapes", "basins": [[{"R": 1, "A": 1}]]}`, false},
+		// The pre-pass only approximates the mandelbrot set.
+		{`{"prePass": 64, "complexFunction": "mandelbrot"}`, true},
+		{`{"prePass": 64, "complexFunction": "z^3 + c"}`, false},
+		{`{"prePass": 64, "complexFunction": "mandelbrot", "zUpdate": "random"}`, false},
+	}
+	for i, test := range tests {
+		filename := filepath.Join(dir, fmt.Sprintf("%d.json", i))
+		if err := ioutil.WriteFile(filename, []byte(test.json), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Parse(filename); (err == nil) != test.ok {
+			t.Errorf("%s: expected valid %t, got error %v", test.json, test.ok, err)
+		}
+	}
+}
+
+func TestFrame(t *testing.T) {
+	b := &Blueprint{Frames: 3, CUpdate: "julia", JuliaReal: -1, JuliaEndReal: 1, Checkpoint: "sweep.gob"}
+	for i, want := range []float64{-1, 0, 1} {
+		frame := b.Frame(i)
+		if frame.JuliaReal != want {
+			t.Errorf("frame %d: expected the julia constant %g, got %g", i, want, frame.JuliaReal)
+		}
+		// The frames are resumed from checkpoints of their own.
+		if path := FramePath("sweep.gob", i); frame.Checkpoint != path {
+			t.Errorf("frame %d: expected the checkpoint %q, got %q", i, path, frame.Checkpoint)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package buddha
+
+import (
+	"context"
+	"sort"
+	"sync/atomic"
+
+	rand7i "github.com/7i/rand"
+
+	"github.com/karlek/wasabi/fractal"
+)
+
+const (
+	// Number of rounds the adaptive sampler explores the importance of the
+	// starting points, before sampling from the importance map.
+	explore = rounds / 10
+	// Probability of choosing a pixel of the importance map uniformly, which
+	// keeps pixels without importance reachable.
+	mix = 0.1
+)
+
+// uniform will try to find orbits like arbitrary, but without searching nearby
+// long orbits, and registers the importance of every starting point.
+func uniform(ctx context.Context, frac *fractal.Fractal, w *worker, rng *rand7i.ComplexRNG, share int64, prog *counter) (total int64) {
+	orbit := fractal.NewOrbit(frac.Iterations)
+	zsrc, csrc := w.sources(frac, rng)
+	var z, c complex128
+	var i int64
+	for i = 0; i < share && ctx.Err() == nil; i++ {
+		c = frac.C(c, csrc)
+		z = frac.Z(c, zsrc)
+		orbit.C = c
+
+		length := Attempt(z, c, orbit, frac)
+		total += length
+		if length > 0 {
+			atomic.AddInt64(&prog.orbits, 1)
+		}
+		importance(z, c, frac, length)
+
+		// Increase progress bar.
+		atomic.AddInt64(&prog.tries, 1)
+	}
+	return total
+}
+
+// distribution is a discrete distribution over the pixels of the importance
+// map, from which the starting points c are chosen.
+type distribution struct {
+	cdf    []float64        // Cumulative probability of the pixels, row by row.
+	inside []float64        // Fraction of the pixels inside the domain of c, row by row.
+	area   float64          // Sum of the fractions inside the domain of c.
+	width  int              // Width of the importance map.
+	imp    *fractal.Fractal // Translates pixels of the importance map to complex points.
+	domain fractal.Domain   // Domain of c, which the chosen points are kept inside.
+}
+
+// newDistribution returns the sampling distribution given by the importance
+// map of the fractal. The importance map covers the bounds of the domain of c,
+// and pixels outside the domain are never chosen.
+func newDistribution(frac *fractal.Fractal) *distribution {
+	d := &distribution{
+		inside: make([]float64, 0, frac.Width*frac.Height),
+		width:  frac.Width,
+		imp:    fractal.Importance(frac),
+		domain: frac.CDomain,
+	}
+	for y := 0; y < frac.Height; y++ {
+		for x := 0; x < frac.Width; x++ {
+			f := d.fraction(x, y)
+			d.inside = append(d.inside, f)
+			d.area += f
+		}
+	}
+
+	var sum float64
+	for _, v := range frac.Importance.Pix {
+		sum += v
+	}
+	d.cdf = make([]float64, 0, len(d.inside))
+	var acc float64
+	for i, v := range frac.Importance.Pix {
+		var p float64
+		switch {
+		case d.inside[i] == 0:
+		case sum > 0:
+			p = mix*d.inside[i]/d.area + (1-mix)*v/sum
+		default:
+			p = d.inside[i] / d.area
+		}
+		acc += p
+		d.cdf = append(d.cdf, acc)
+	}
+	return d
+}
+
+// subpixels is the number of points along each axis of a pixel which estimate
+// the fraction of the pixel inside the domain of c.
+const subpixels = 4
+
+// fraction returns the fraction of the points of the pixel (x, y) which are
+// inside the domain of c.
+func (d *distribution) fraction(x, y int) float64 {
+	var n int
+	for i := 0; i < subpixels; i++ {
+		for j := 0; j < subpixels; j++ {
+			u := float64(x) + (float64(i)+0.5)/subpixels
+			v := float64(y) + (float64(j)+0.5)/subpixels
+			if d.domain.Contains(d.imp.Camera.ToPlane(u, v)) {
+				n++
+			}
+		}
+	}
+	return float64(n) / (subpixels * subpixels)
+}
+
+// point returns a random point c inside the domain and a pixel chosen from the
+// distribution, and the weight of the point: the ratio between the density of
+// c when sampled uniformly from the domain and from the distribution.
+func (d *distribution) point(rng *rand7i.ComplexRNG) (complex128, float64) {
+	total := d.cdf[len(d.cdf)-1]
+	i := sort.SearchFloat64s(d.cdf, unit(rng)*total)
+	p := d.cdf[i]
+	if i > 0 {
+		p -= d.cdf[i-1]
+	}
+	x, y := i%d.width, i/d.width
+	for {
+		c := d.imp.Camera.ToPlane(float64(x)+unit(rng), float64(y)+unit(rng))
+		if d.domain.Contains(c) {
+			return c, d.inside[i] * total / (p * d.area)
+		}
+	}
+}
+
+// sample will try to find orbits by choosing starting points from the
+// distribution. Each orbit is weighted by the ratio between the density of its
+// starting point if chosen uniformly from the domain of c, as in the exploring
+// rounds, and from the distribution, which keeps the density of the
+// histograms unbiased.
+func (d *distribution) sample(ctx context.Context, frac *fractal.Fractal, w *worker, rng *rand7i.ComplexRNG, share int64, prog *counter) (total int64) {
+	orbit := fractal.NewOrbit(frac.Iterations)
+	zsrc, _ := w.sources(frac, rng)
+	var i int64
+	for i = 0; i < share && ctx.Err() == nil; i++ {
+		c, weight := d.point(rng)
+		z := frac.Z(c, zsrc)
+		orbit.C = c
+		orbit.Weight = weight
+
+		length := Attempt(z, c, orbit, frac)
+		total += length
+		if length > 0 {
+			atomic.AddInt64(&prog.orbits, 1)
+		}
+
+		// Increase progress bar.
+		atomic.AddInt64(&prog.tries, 1)
+	}
+	return total
+}
@@ -3,9 +3,12 @@
 package buddha
 
 import (
+	"context"
 	"image"
 	"math"
+	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	rand7i "github.com/7i/rand"
@@ -13,69 +16,377 @@ import (
 	"github.com/karlek/progress/barcli"
 	"github.com/karlek/wasabi/coloring"
 	"github.com/karlek/wasabi/fractal"
+	"github.com/karlek/wasabi/histo"
+	"github.com/sirupsen/logrus"
 )
 
+// rounds is the number of rounds the tries are divided into. The histograms of
+// the workers are merged and checkpointed between rounds.
+const rounds = 100
+
 // FillHistograms creates a number of workers which finds orbits and stores
-// their points in a histogram.
+// their points in a histogram. Each worker registers its orbits in histograms
+// of its own, which are merged in order after each round; the result is
+// therefore deterministic for a given seed and number of workers.
 func FillHistograms(frac *fractal.Fractal, workers int) float64 {
+	res, err := ResumeHistograms(context.Background(), frac, workers, nil)
+	if err != nil {
+		logrus.Warnln("[!]", err)
+	}
+	return res.OrbitRatio
+}
+
+// ResumeHistograms continues to fill the histograms from the checkpoint cp, or
+// from the beginning if cp is nil, while printing a progress bar. The render
+// stops when the context is cancelled, see FillHistogramsContext.
+func ResumeHistograms(ctx context.Context, frac *fractal.Fractal, workers int, cp *Checkpoint) (Result, error) {
 	bar, _ := barcli.New(int(frac.Tries * float64(frac.Width*frac.Height)))
-	go func(bar *barcli.Bar) {
-		for {
-			if bar.Done() {
-				return
-			}
+	var prev int64
+	res, err := FillHistogramsContext(ctx, frac, Options{
+		Workers: workers,
+		Resume:  cp,
+		Progress: func(p Progress) {
+			bar.IncN(int(p.Tries - prev))
+			prev = p.Tries
 			bar.Print()
-			time.Sleep(1000 * time.Millisecond)
-		}
-	}(bar)
+		},
+	})
+	bar.SetMax()
+	bar.Print()
+	return res, err
+}
 
-	wg := new(sync.WaitGroup)
-	wg.Add(workers)
+// Options contains the options of FillHistogramsContext.
+type Options struct {
+	Workers  int            // Number of workers, defaults to the number of CPUs.
+	Resume   *Checkpoint    // Checkpoint to resume from, nil starts from the beginning.
+	Progress func(Progress) // Called periodically with the progress of the render, may be nil.
+	Interval time.Duration  // Duration between progress calls, defaults to one second.
+}
+
+// Progress describes the progress of a render.
+type Progress struct {
+	Tries   int64         // Number of orbit attempts done.
+	Orbits  int64         // Number of orbits registered.
+	Elapsed time.Duration // Time since the render was started.
+	Noise   float64       // Noise of the histograms after the last round.
+}
+
+// Result describes the outcome of a render.
+type Result struct {
+	OrbitRatio float64 // Ratio of registered pixels per orbit attempt.
+	Noise      float64 // Noise of the histograms after the last round.
+	Rounds     int64   // Number of rounds sampled, including resumed ones.
+}
+
+// counter counts the progress of the workers. The fields are updated
+// atomically.
+type counter struct {
+	tries  int64
+	orbits int64
+	noise  uint64 // Bits of the float64 noise.
+}
+
+// FillHistogramsContext fills the histograms of the fractal. If the context is
+// cancelled the workers stop, the orbits registered so far are kept in the
+// histograms of frac and the context's error is returned.
+//
+// The orbit attempts are divided into rounds, each seeded anew from the seed of
+// the fractal, the round and the worker. If frac.Checkpoint is set, a
+// checkpoint is saved after each round where frac.CheckpointInterval has
+// elapsed since the previous one. The number of workers of a resumed render is
+// taken from the checkpoint, so that the result is the same as an
+// uninterrupted render.
+//
+// Sampling stops early after the round where frac.TimeBudget is exceeded or the
+// noise falls below frac.Noise. A checkpoint is then saved, so that the render
+// may be resumed with a larger budget. A cancelled render saves a checkpoint of
+// the rounds completed before the cancellation. Checkpoints which can't be
+// saved stop the render with a *CheckpointError, which replaces the context's
+// error of a cancelled render; the cancellation is then given by the context.
+func FillHistogramsContext(ctx context.Context, frac *fractal.Fractal, opts Options) (Result, error) {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
 
 	orbitTries := int64(frac.Tries * float64(frac.Width*frac.Height))
 
+	var start, totals int64
+	if cp := opts.Resume; cp != nil {
+		if err := cp.restore(frac); err != nil {
+			return Result{}, err
+		}
+		start, totals, workers = cp.Round, cp.Totals, cp.Workers
+	}
 	share := orbitTries / int64(workers)
-	totChan := make(chan int64)
+	ws := make([]*worker, workers)
+	for n := range ws {
+		ws[n] = newWorker(frac, n, workers, share)
+	}
+	if cp := opts.Resume; cp != nil && len(cp.States) == workers {
+		for n, state := range cp.States {
+			ws[n].restore(state)
+		}
+	}
 
-	for n := 0; n < workers; n++ {
-		// Our worker channel to send our orbits on!
-		rng := rand7i.NewComplexRNG(int64(n+1) + frac.Seed)
-		go arbitrary(totChan, frac, &rng, share, wg, bar)
+	// Progress shared by the workers.
+	prog := &counter{
+		tries: share * start / rounds * int64(workers),
+		noise: math.Float64bits(math.Inf(1)),
 	}
-	wg.Wait()
+	begin := time.Now()
+	report := func() {
+		if opts.Progress == nil {
+			return
+		}
+		opts.Progress(Progress{
+			Tries:   atomic.LoadInt64(&prog.tries),
+			Orbits:  atomic.LoadInt64(&prog.orbits),
+			Elapsed: time.Since(begin),
+			Noise:   math.Float64frombits(atomic.LoadUint64(&prog.noise)),
+		})
+	}
+	quit, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			report()
+			select {
+			case <-quit:
+				return
+			case <-time.After(interval):
+			}
+		}
+	}()
+
+	var err error
+	last := time.Now()
+	// Histograms of the workers, which are reused across the rounds.
+	locals := make([]*fractal.Fractal, workers)
+	for n := range locals {
+		locals[n] = local(frac)
+	}
+	results := make([]int64, workers)
+	// Sampling distribution of the adaptive sampler.
+	var dist *distribution
+	// Sum of the histograms after the previous round, to measure the noise.
+	prev := histo.New(frac.R.Width, frac.R.Height)
+	if start > 0 {
+		noise(prev, frac)
+	}
+	round := start
+	for ; round < rounds; round++ {
+		// Number of orbit attempts of each worker in this round.
+		tries := share*(round+1)/rounds - share*round/rounds
+		// State of the workers before the round, which a cancelled round is
+		// checkpointed with.
+		before := states(ws)
+
+		// Choose how the workers sample the starting points of the orbits.
+		sample := arbitrary
+		switch {
+		case frac.Sampler == fractal.Metropolis:
+			sample = metropolis
+		case frac.Sampler == fractal.Adaptive && round < explore:
+			sample = uniform
+		case frac.Sampler == fractal.Adaptive:
+			// The importance map is complete after the exploring rounds.
+			if dist == nil {
+				dist = newDistribution(frac)
+			}
+			sample = dist.sample
+		}
+
+		wg := new(sync.WaitGroup)
+		wg.Add(workers)
+		for n := range locals {
+			// Each round is seeded anew, so that the state of the random number
+			// generators is given by the round.
+			rng := rand7i.NewComplexRNG(round*int64(workers) + int64(n+1) + frac.Seed)
+			go func(n int, rng *rand7i.ComplexRNG) {
+				defer wg.Done()
+				results[n] = sample(ctx, locals[n], ws[n], rng, tries, prog)
+			}(n, &rng)
+		}
+		wg.Wait()
+
+		err = ctx.Err()
+		if err != nil && frac.Checkpoint != "" {
+			// The orbits of the cancelled round are not yet reduced.
+			if serr := save(frac, round, totals, before); serr != nil {
+				err = serr
+			}
+		}
+		// Reduce the histograms of the workers, even if the round was cancelled.
+		for n, l := range locals {
+			totals += results[n]
+			reduce(frac, l)
+		}
+		if err != nil {
+			break
+		}
+		atomic.StoreUint64(&prog.noise, math.Float64bits(noise(prev, frac)))
 
-	var totals int64
-	for tot := range totChan {
-		workers--
-		totals += tot
-		if workers == 0 {
-			close(totChan)
+		if frac.Checkpoint != "" && (budgeted(frac, prog, begin) || time.Since(last) >= frac.CheckpointInterval) {
+			last = time.Now()
+			if err = save(frac, round+1, totals, states(ws)); err != nil {
+				break
+			}
+		}
+		if budgeted(frac, prog, begin) {
 			break
 		}
 	}
-	bar.SetMax()
-	bar.Print()
+	// The cancelled or budgeted round was sampled as well.
+	if round < rounds {
+		round++
+	}
+
+	close(quit)
+	<-stopped
+	report()
+
+	return Result{
+		OrbitRatio: float64(totals) / (float64(orbitTries) * float64(round) / rounds),
+		Noise:      math.Float64frombits(atomic.LoadUint64(&prog.noise)),
+		Rounds:     round,
+	}, err
+}
+
+// worker is the state of a worker which is continued across the rounds of a
+// render.
+type worker struct {
+	tries int64            // Number of orbit attempts of the worker in all rounds.
+	chain Chain            // Markov chain of the metropolis sampler.
+	z, c  fractal.Sequence // Sequences of the points z and c, nil for random sources.
+}
+
+// newWorker returns the n:th of the workers of the fractal with the given share
+// of the orbit attempts.
+func newWorker(frac *fractal.Fractal, n, workers int, share int64) *worker {
+	// The sequences are rotated by a generator seeded as in the round after
+	// the last, apart from the rounds.
+	rng := rand7i.NewComplexRNG(rounds*int64(workers) + int64(n+1) + frac.Seed)
+	w := &worker{tries: share}
+	w.z, _ = source(frac.ZSource, &rng).(fractal.Sequence)
+	w.c, _ = source(frac.CSource, &rng).(fractal.Sequence)
+	return w
+}
+
+// sources returns the sources of the points z and c of the worker in a round.
+// The sequences continue across the rounds, while random sources share the
+// random number generator of the round.
+func (w *worker) sources(frac *fractal.Fractal, rng *rand7i.ComplexRNG) (z, c fractal.Source) {
+	z, c = w.z, w.c
+	if w.z == nil {
+		z = source(frac.ZSource, rng)
+	}
+	if w.c == nil {
+		c = source(frac.CSource, rng)
+	}
+	return z, c
+}
 
-	return float64(totals) / float64(orbitTries)
+// State is the state of a worker saved in checkpoints.
+type State struct {
+	Chain Chain  // Markov chain of the metropolis sampler.
+	Z, C  uint64 // Indices of the sequences of the points z and c.
+}
+
+// states returns the states of the workers.
+func states(ws []*worker) []State {
+	ss := make([]State, len(ws))
+	for n, w := range ws {
+		ss[n].Chain = w.chain
+		if w.z != nil {
+			ss[n].Z = w.z.Index()
+		}
+		if w.c != nil {
+			ss[n].C = w.c.Index()
+		}
+	}
+	return ss
+}
+
+// restore restores the state of the worker from a checkpoint.
+func (w *worker) restore(s State) {
+	w.chain = s.Chain
+	if w.z != nil {
+		w.z.Seek(s.Z)
+	}
+	if w.c != nil {
+		w.c.Seek(s.C)
+	}
+}
+
+// budgeted returns true if the time budget of the fractal is exceeded or the
+// noise has fallen below the targeted noise.
+func budgeted(frac *fractal.Fractal, prog *counter, begin time.Time) bool {
+	if frac.TimeBudget > 0 && time.Since(begin) >= frac.TimeBudget {
+		return true
+	}
+	return frac.Noise > 0 && math.Float64frombits(atomic.LoadUint64(&prog.noise)) < frac.Noise
+}
+
+// local returns a copy of the fractal with histograms of its own, so that a
+// worker can register orbits without synchronization.
+func local(frac *fractal.Fractal) *fractal.Fractal {
+	l := *frac
+	l.Clear()
+	if usesImportance(frac) {
+		l.Importance = histo.New(frac.Width, frac.Height)
+	}
+	return &l
+}
+
+// reduce adds the histograms of the worker's fractal l to frac, and clears them
+// for the next round.
+func reduce(frac, l *fractal.Fractal) {
+	// The histograms have the same dimensions, so merging can't fail.
+	frac.R, _ = histo.Merge(l.R, frac.R)
+	frac.G, _ = histo.Merge(l.G, frac.G)
+	frac.B, _ = histo.Merge(l.B, frac.B)
+	l.R.Reset()
+	l.G.Reset()
+	l.B.Reset()
+	if usesImportance(frac) {
+		frac.Importance, _ = histo.Merge(l.Importance, frac.Importance)
+		l.Importance.Reset()
+	}
+}
+
+// usesImportance returns true if the importance histogram of the fractal is
+// plotted or used for sampling.
+func usesImportance(frac *fractal.Fractal) bool {
+	return frac.PlotImportance || frac.Sampler == fractal.Adaptive
 }
 
 // arbitrary will try to find orbits in the complex function by choosing a
 // random point in it's domain and iterating it a number of times to see if it
 // converges or diverges.
-func arbitrary(totChan chan int64, frac *fractal.Fractal, rng *rand7i.ComplexRNG, share int64, wg *sync.WaitGroup, bar *barcli.Bar) {
-	orbit := &fractal.Orbit{Points: make([]complex128, frac.Iterations)}
+func arbitrary(ctx context.Context, frac *fractal.Fractal, w *worker, rng *rand7i.ComplexRNG, share int64, prog *counter) (total int64) {
+	orbit := fractal.NewOrbit(frac.Iterations)
+	zsrc, csrc := w.sources(frac, rng)
 	var z, c complex128
-	var total, i int64
-	for i = 0; i < share; i++ {
+	var i int64
+	for i = 0; i < share && ctx.Err() == nil; i++ {
 		// Our random points which, hopefully, will create an orbit!
-		c = frac.C(c, rng)
-		z = frac.Z(c, rng)
+		c = frac.C(c, csrc)
+		z = frac.Z(c, zsrc)
 		orbit.C = c
 
 		length := Attempt(z, c, orbit, frac)
 		total += length
+		if length > 0 {
+			atomic.AddInt64(&prog.orbits, 1)
+		}
 		if IsLongOrbit(length, frac) {
-			i += searchNearby(z, orbit, frac, &total, bar)
+			i += searchNearby(z, orbit, frac, &total, prog)
 		}
 
 		// Plot sampling map.
@@ -84,14 +395,28 @@ func arbitrary(totChan chan int64, frac *fractal.Fractal, rng *rand7i.ComplexRNG
 		}
 
 		// Increase progress bar.
-		bar.Inc()
+		atomic.AddInt64(&prog.tries, 1)
 	}
-	wg.Done()
-	go func() { totChan <- total }()
+	return total
+}
+
+// source returns the source of points created by newSource, which defaults to
+// NewRandom.
+func source(newSource func(*rand7i.ComplexRNG) fractal.Source, rng *rand7i.ComplexRNG) fractal.Source {
+	if newSource == nil {
+		return fractal.NewRandom(rng)
+	}
+	return newSource(rng)
 }
 
 // Attempt tries to find valid orbit from the points z and c and returns the length of the orbit inside the image space.
 func Attempt(z, c complex128, orbit *fractal.Orbit, frac *fractal.Fractal) int64 {
+	return register(orbitLength(z, c, orbit, frac), orbit, frac)
+}
+
+// orbitLength iterates the points z and c and returns the length of the orbit,
+// or zero if the orbit should not be registered.
+func orbitLength(z, c complex128, orbit *fractal.Orbit, frac *fractal.Fractal) int64 {
 	// Iterations completed by the complex function.
 	iterations := frac.Register(z, c, orbit, frac)
 	// Reject unregistered orbits.
@@ -102,7 +427,16 @@ func Attempt(z, c complex128, orbit *fractal.Orbit, frac *fractal.Fractal) int64
 	if iterations < frac.Threshold {
 		return 0
 	}
+	if frac.Filter != nil && !frac.Filter.Accept(iterations, orbit) {
+		return 0
+	}
+	return iterations
+}
 
+// register registers the first iterations points of the orbit with the
+// coloring method of the fractal and returns the number of pixels registered
+// inside the image space.
+func register(iterations int64, orbit *fractal.Orbit, frac *fractal.Fractal) int64 {
 	// The number of pixels we registered inside the image space.
 	var pixels int64
 	switch frac.Method.Mode() {
@@ -116,6 +450,8 @@ func Attempt(z, c complex128, orbit *fractal.Orbit, frac *fractal.Fractal) int64
 		pixels = registerField(iterations, orbit, frac)
 	case coloring.Path:
 		pixels = registerPaths(iterations, orbit, frac)
+	case coloring.Nebula:
+		pixels = registerNebula(iterations, orbit, frac)
 	}
 	return pixels
 }
@@ -128,31 +464,37 @@ func IsLongOrbit(length int64, frac *fractal.Fractal) bool {
 }
 
 // searchNearby samples points from nearby a point which rendered a long orbit
-// with increasingly smaller larger steps out from the point.
-func searchNearby(z complex128, orbit *fractal.Orbit, frac *fractal.Fractal, total *int64, bar *barcli.Bar) (i int64) {
+// with increasingly smaller larger steps out from the point. The starting point
+// c is perturbed, or z for julia buddhabrots where c is constant.
+func searchNearby(z complex128, orbit *fractal.Orbit, frac *fractal.Fractal, total *int64, prog *counter) (i int64) {
 	h, tol := 1e-15, 1e-2
 	var orbits int64
 
 outer:
 	for ; h < tol; h *= 1e1 {
-		cr, ci := real(orbit.C), imag(orbit.C)
-
-		cs := []complex128{
-			complex(cr+h, ci),
-			complex(cr-h, ci),
-			complex(cr, ci+h),
-			complex(cr, ci-h),
-			complex(cr+h, ci+h),
-			complex(cr-h, ci-h),
-			complex(cr+h, ci-h),
-			complex(cr-h, ci+h),
+		ds := []complex128{
+			complex(h, 0),
+			complex(-h, 0),
+			complex(0, h),
+			complex(0, -h),
+			complex(h, h),
+			complex(-h, -h),
+			complex(h, -h),
+			complex(-h, h),
 		}
 
-		for _, cprim := range cs {
-			bar.Inc()
+		for _, d := range ds {
+			atomic.AddInt64(&prog.tries, 1)
 
-			length := Attempt(z, cprim, orbit, frac)
+			zprim, cprim := z, orbit.C+d
+			if frac.Julia {
+				zprim, cprim = z+d, orbit.C
+			}
+			length := Attempt(zprim, cprim, orbit, frac)
 			(*total) += length
+			if length > 0 {
+				atomic.AddInt64(&prog.orbits, 1)
+			}
 
 			if frac.PlotImportance {
 				importance(z, orbit.C, frac, length)
@@ -171,24 +513,58 @@ outer:
 // increases it's histogram values. Points outside the image canvas are
 // ignored.
 func registerPoint(z complex128, orbit *fractal.Orbit, frac *fractal.Fractal, red, green, blue float64) int64 {
-	if pt, ok := frac.Point(z, orbit.C); ok {
-		increase(pt, red, green, blue, frac)
-		return 1
+	if frac.Splat == nil {
+		if pt, ok := frac.CellPoint(z, orbit.C); ok {
+			increase(pt, orbit.Weight*red, orbit.Weight*green, orbit.Weight*blue, frac)
+			return 1
+		}
+		return 0
+	}
+	x, y := frac.Cell(z, orbit.C)
+	// Negated to reject NaN.
+	if !(x >= 0 && y >= 0 && x < float64(frac.R.Width) && y < float64(frac.R.Height)) {
+		return 0
+	}
+	splat(x, y, orbit.Weight*red, orbit.Weight*green, orbit.Weight*blue, frac)
+	return 1
+}
+
+// splat distributes the color values of the point at the continuous cell
+// coordinates (x, y) to the neighbouring cells with the splat kernel of the
+// fractal. The values distributed outside the histograms are lost.
+func splat(x, y float64, red, green, blue float64, frac *fractal.Fractal) {
+	var wx, wy [fractal.MaxTaps]float64
+	x0, nx := frac.Splat.Taps(x, &wx)
+	y0, ny := frac.Splat.Taps(y, &wy)
+	for i := 0; i < nx; i++ {
+		cx := x0 + i
+		if cx < 0 || cx >= frac.R.Width {
+			continue
+		}
+		for j := 0; j < ny; j++ {
+			cy := y0 + j
+			if cy < 0 || cy >= frac.R.Height {
+				continue
+			}
+			w := wx[i] * wy[j]
+			increase(image.Point{X: cx, Y: cy}, w*red, w*green, w*blue, frac)
+		}
 	}
-	return 0
 }
 
 // increase adds the color values for the point pt to their respective
 // histograms.
 func increase(pt image.Point, red, green, blue float64, frac *fractal.Fractal) {
+	// The histograms have the same dimensions.
+	i := frac.R.Index(pt.X, pt.Y)
 	if red != 0 {
-		frac.R[pt.X][pt.Y] += red
+		frac.R.Pix[i] += red
 	}
 	if green != 0 {
-		frac.G[pt.X][pt.Y] += green
+		frac.G.Pix[i] += green
 	}
 	if blue != 0 {
-		frac.B[pt.X][pt.Y] += blue
+		frac.B.Pix[i] += blue
 	}
 }
 
@@ -198,7 +574,7 @@ func increase(pt image.Point, red, green, blue float64, frac *fractal.Fractal) {
 func registerColoredOrbit(it int64, orbit *fractal.Orbit, frac *fractal.Fractal) (sum int64) {
 	// Get color from gradient based on iteration count of the orbit.
 	for i, p := range orbit.Points[:it] {
-		red, green, blue := frac.Method.Get(int64(i), frac.Iterations)
+		red, green, blue := frac.Method.Basin(orbit.Root).Get(int64(i), frac.Iterations)
 		sum += registerPoint(p, orbit, frac, red, green, blue)
 	}
 	return sum
@@ -208,7 +584,20 @@ func registerColoredOrbit(it int64, orbit *fractal.Orbit, frac *fractal.Fractal)
 // on it's iteration count.
 func registerOrbit(it int64, orbit *fractal.Orbit, frac *fractal.Fractal) (sum int64) {
 	// Get color from gradient based on iteration count of the orbit.
-	red, green, blue := frac.Method.Get(it, frac.Iterations)
+	red, green, blue := frac.Method.Basin(orbit.Root).Get(it, frac.Iterations)
+	for _, p := range orbit.Points[:it] {
+		sum += registerPoint(p, orbit, frac, red, green, blue)
+	}
+	return sum
+}
+
+// registerNebula registers the points of an orbit in the channels of the
+// nebulabrot whose iteration limits and thresholds the orbit satisfies.
+func registerNebula(it int64, orbit *fractal.Orbit, frac *fractal.Fractal) (sum int64) {
+	red, green, blue := frac.Nebula.Channels(it)
+	if red+green+blue == 0 {
+		return 0
+	}
 	for _, p := range orbit.Points[:it] {
 		sum += registerPoint(p, orbit, frac, red, green, blue)
 	}
@@ -221,6 +610,6 @@ func importance(z, c complex128, frac *fractal.Fractal, length int64) {
 	imp := fractal.Importance(frac)
 	if p, ok := imp.Point(z, c); ok {
 		inc := float64(length) / float64(frac.Iterations)
-		frac.Importance[p.X][p.Y] += inc
+		frac.Importance.Add(p.X, p.Y, inc)
 	}
 }
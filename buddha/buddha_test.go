@@ -0,0 +1,319 @@
+package buddha
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"math"
+	"math/cmplx"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	rand7i "github.com/7i/rand"
+
+	"github.com/karlek/wasabi/coloring"
+	"github.com/karlek/wasabi/fractal"
+	"github.com/karlek/wasabi/histo"
+	"github.com/karlek/wasabi/iro"
+	"github.com/karlek/wasabi/mandel"
+)
+
+// newFractal returns a small buddhabrot suitable for tests.
+func newFractal() *fractal.Fractal {
+	colors := []iro.Color{
+		iro.RGBA{R: 1, G: 0, B: 0, A: 1},
+		iro.RGBA{R: 0, G: 1, B: 0, A: 1},
+		iro.RGBA{R: 0, G: 0, B: 1, A: 1},
+	}
+	method := coloring.NewColoring(iro.RGBA{A: 1}, coloring.IterationCount, colors, []float64{0, 0.1, 0.5})
+	frac, err := fractal.FromConfig(fractal.Config{
+		Width:          64,
+		Height:         64,
+		Iterations:     200,
+		Method:         method,
+		Bailout:        4,
+		Func:           mandel.Mandelbrot,
+		Coef:           1,
+		Register:       mandel.Escaped,
+		Offset:         complex(0.5, 0),
+		PlotImportance: true,
+		Seed:           1,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return frac
+}
+
+func TestFillHistogramsDeterministic(t *testing.T) {
+	for _, sampler := range []fractal.Sampler{fractal.Uniform, fractal.Metropolis, fractal.Adaptive} {
+		a, b := newFractal(), newFractal()
+		a.Sampler, b.Sampler = sampler, sampler
+		ra := FillHistograms(a, 4)
+		rb := FillHistograms(b, 4)
+		if ra != rb {
+			t.Errorf("%v: orbit ratio differs between renders: %f != %f", sampler, ra, rb)
+		}
+		if !reflect.DeepEqual(a.R, b.R) || !reflect.DeepEqual(a.G, b.G) || !reflect.DeepEqual(a.B, b.B) {
+			t.Errorf("%v: histograms differs between renders with the same seed", sampler)
+		}
+		if !reflect.DeepEqual(a.Importance, b.Importance) {
+			t.Errorf("%v: importance differs between renders with the same seed", sampler)
+		}
+	}
+}
+
+func TestFillHistogramsContextResume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wasabi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, test := range []struct {
+		sampler fractal.Sampler
+		source  func(*rand7i.ComplexRNG) fractal.Source
+	}{
+		{fractal.Uniform, fractal.NewRandom},
+		{fractal.Metropolis, fractal.NewRandom},
+		{fractal.Adaptive, fractal.NewRandom},
+		// The sequences continue where the checkpoint left them.
+		{fractal.Uniform, fractal.NewSobol(fractal.DimC)},
+	} {
+		sampler := test.sampler
+		newFractal := func() *fractal.Fractal {
+			frac := newFractal()
+			frac.Sampler = sampler
+			frac.C = fractal.RandomPoint
+			frac.CSource = test.source
+			return frac
+		}
+		want := newFractal()
+		if _, err := FillHistogramsContext(context.Background(), want, Options{Workers: 2}); err != nil {
+			t.Fatal(err)
+		}
+
+		// Cancel the render halfway through.
+		ctx, cancel := context.WithCancel(context.Background())
+		var calls int64
+		frac := newFractal()
+		frac.Checkpoint = filepath.Join(dir, sampler.String())
+		// Only the cancellation saves a checkpoint.
+		frac.CheckpointInterval = time.Hour
+		frac.Register = func(z, c complex128, orbit *fractal.Orbit, frac *fractal.Fractal) int64 {
+			if atomic.AddInt64(&calls, 1) == int64(frac.Tries*float64(frac.Width*frac.Height))/2 {
+				cancel()
+			}
+			return mandel.Escaped(z, c, orbit, frac)
+		}
+		_, err := FillHistogramsContext(ctx, frac, Options{Workers: 2})
+		cancel()
+		if err != context.Canceled {
+			t.Fatalf("%v: expected %v, got %v", sampler, context.Canceled, err)
+		}
+
+		cp, err := LoadCheckpoint(frac.Checkpoint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cp.Round == 0 || cp.Round == rounds {
+			t.Fatalf("%v: expected checkpoint of a partial render, got round %d", sampler, cp.Round)
+		}
+		got := newFractal()
+		if _, err := FillHistogramsContext(context.Background(), got, Options{Resume: cp}); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(want.R, got.R) || !reflect.DeepEqual(want.G, got.G) || !reflect.DeepEqual(want.B, got.B) {
+			t.Errorf("%v: resumed render differs from uninterrupted render", sampler)
+		}
+	}
+}
+
+func TestFillHistogramsCheckpointError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	frac := newFractal()
+	frac.Checkpoint = filepath.Join(os.DevNull, "checkpoint")
+	// The lost checkpoint of the cancellation is reported, while the context
+	// tells that the render was cancelled.
+	_, err := FillHistogramsContext(ctx, frac, Options{Workers: 2})
+	var cerr *CheckpointError
+	if !errors.As(err, &cerr) {
+		t.Errorf("expected a checkpoint error, got %v", err)
+	}
+}
+
+func TestFillHistogramsBudget(t *testing.T) {
+	frac := newFractal()
+	frac.TimeBudget = time.Nanosecond
+	res, err := FillHistogramsContext(context.Background(), frac, Options{Workers: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Rounds != 1 {
+		t.Errorf("expected time budget to stop after the first round, got %d rounds", res.Rounds)
+	}
+
+	frac = newFractal()
+	frac.Noise = 0.5
+	res, err = FillHistogramsContext(context.Background(), frac, Options{Workers: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Rounds == rounds || res.Noise >= frac.Noise {
+		t.Errorf("expected noise budget to stop early, got noise %f after %d rounds", res.Noise, res.Rounds)
+	}
+}
+
+func TestFillHistogramsMetropolisDomain(t *testing.T) {
+	frac := newFractal()
+	disc := fractal.Disc{Center: -0.5, Radius: 0.3}
+	frac.Sampler = fractal.Metropolis
+	frac.C = fractal.InDomain(disc)
+	frac.CDomain = disc
+	frac.Tries = 4
+	FillHistograms(frac, 2)
+	// The importance map registers the states of the chains, which must not
+	// wander outside the domain.
+	imp := fractal.Importance(frac)
+	diagonal := math.Sqrt2 / imp.Camera.Scale
+	for y := 0; y < frac.Importance.Height; y++ {
+		for x, v := range frac.Importance.Row(y) {
+			if c := imp.ImageToComplex(x, y); v != 0 && cmplx.Abs(c-disc.Center) > disc.Radius+diagonal/2 {
+				t.Fatalf("state %v outside the domain", c)
+			}
+		}
+	}
+}
+
+func TestDistributionUnbiased(t *testing.T) {
+	disc := fractal.Disc{Center: -0.5, Radius: 1.2}
+	for _, test := range []struct {
+		width, height int
+		domain        fractal.Domain
+	}{
+		{96, 64, nil},
+		{64, 96, nil},
+		{96, 64, disc},
+	} {
+		frac := newFractal()
+		frac.Width, frac.Height = test.width, test.height
+		frac.Camera = fractal.NewCamera(test.width, test.height, -0.5, 1)
+		if test.domain != nil {
+			frac.C = fractal.InDomain(test.domain)
+			frac.CDomain = test.domain
+		}
+		frac.Clear()
+		frac.Importance = histo.New(test.width, test.height)
+
+		// The orbits chosen from the importance map weigh as much in total as
+		// the uniformly sampled orbits of the map.
+		rng := rand7i.NewComplexRNG(1)
+		const tries = 50000
+		w := newWorker(frac, 0, 1, tries)
+		uniform(context.Background(), frac, w, &rng, tries, new(counter))
+		want := sum(frac.R, frac.G, frac.B)
+		d := newDistribution(frac)
+		frac.Clear()
+		d.sample(context.Background(), frac, w, &rng, tries, new(counter))
+		if got := sum(frac.R, frac.G, frac.B); math.Abs(got-want) > 0.05*want {
+			t.Errorf("%dx%d %T: expected the total weight %g, got %g", test.width, test.height, test.domain, want, got)
+		}
+	}
+}
+
+func TestFillHistogramsJulia(t *testing.T) {
+	frac := newFractal()
+	frac.Julia = true
+	frac.Z = fractal.RandomPoint
+	frac.C = func(complex128, fractal.Source) complex128 { return complex(-0.8, 0.156) }
+	if ratio := FillHistograms(frac, 2); ratio == 0 {
+		t.Fatal("no orbits registered for the julia constant")
+	}
+	if histo.Max(frac.R)+histo.Max(frac.G)+histo.Max(frac.B) == 0 {
+		t.Error("empty histograms for the julia constant")
+	}
+}
+
+func TestFillHistogramsNebula(t *testing.T) {
+	frac := newFractal()
+	frac.Method = coloring.NewColoring(iro.RGBA{A: 1}, coloring.Nebula, []iro.Color{iro.RGBA{A: 1}, iro.RGBA{R: 1, G: 1, B: 1, A: 1}}, []float64{0, 1})
+	frac.Nebula = &fractal.Nebula{Iterations: [3]int64{20, 50, 200}}
+	FillHistograms(frac, 2)
+	// Orbits below the limit of a channel are below the larger limits as well.
+	for i, r := range frac.R.Pix {
+		if g, b := frac.G.Pix[i], frac.B.Pix[i]; r > g || g > b {
+			t.Fatalf("cell %d: expected channels ordered by their limits, got (%g, %g, %g)", i, r, g, b)
+		}
+	}
+	if histo.Max(frac.R) == 0 || histo.Max(frac.B) <= histo.Max(frac.R) {
+		t.Errorf("expected the channel of the largest limit to register the most orbits")
+	}
+}
+
+func TestFillHistogramsSupersampling(t *testing.T) {
+	want, got := newFractal(), newFractal()
+	got.Supersampling = 3
+	got.Clear()
+	FillHistograms(want, 4)
+	FillHistograms(got, 4)
+	if got.R.Width != 3*want.Width || got.R.Height != 3*want.Height {
+		t.Fatalf("expected histograms of %dx%d cells, got %dx%d", 3*want.Width, 3*want.Height, got.R.Width, got.R.Height)
+	}
+	// Every point falls in a cell of the pixel it falls in without
+	// supersampling.
+	hs := [][2]histo.Histo{{want.R, got.R}, {want.G, got.G}, {want.B, got.B}}
+	for _, h := range hs {
+		down := histo.Downsample(h[1], got.Supersampling)
+		for i, w := range h[0].Pix {
+			if math.Abs(down.Pix[i]-w) > 1e-9*w {
+				t.Fatalf("cell %d: expected %g, got %g", i, w, down.Pix[i])
+			}
+		}
+	}
+	if sum(want.R, want.G, want.B) == 0 {
+		t.Error("empty histograms")
+	}
+}
+
+func TestFillHistogramsSplat(t *testing.T) {
+	want := newFractal()
+	FillHistograms(want, 4)
+	for _, splat := range []fractal.Splat{{Kernel: fractal.Bilinear}, {Kernel: fractal.Tent, Radius: 1.5}, {Kernel: fractal.Gaussian, Radius: 1}} {
+		got := newFractal()
+		got.Splat = &splat
+		FillHistograms(got, 4)
+		// The weight of the points is kept, except for the little splatted
+		// outside the histograms.
+		if w, g := sum(want.R, want.G, want.B), sum(got.R, got.G, got.B); math.Abs(w-g) > 1e-2*w {
+			t.Errorf("%v: expected the total weight %g, got %g", splat.Kernel, w, g)
+		}
+		if w, g := cells(want.R), cells(got.R); g <= w {
+			t.Errorf("%v: expected the points spread over more than %d cells, got %d", splat.Kernel, w, g)
+		}
+	}
+}
+
+// sum returns the sum of the cells of the histograms.
+func sum(hs ...histo.Histo) (s float64) {
+	for _, h := range hs {
+		for _, v := range h.Pix {
+			s += v
+		}
+	}
+	return s
+}
+
+// cells returns the number of non-zero cells of the histogram.
+func cells(h histo.Histo) (n int) {
+	for _, v := range h.Pix {
+		if v != 0 {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,118 @@
+package buddha
+
+import (
+	"encoding/gob"
+	"fmt"
+	"os"
+
+	"github.com/karlek/wasabi/fractal"
+	"github.com/karlek/wasabi/histo"
+)
+
+// Checkpoint is a snapshot of the histograms of a render in progress, from
+// which the sampling can be resumed.
+type Checkpoint struct {
+	Round   int64   // Number of completed rounds.
+	Totals  int64   // Number of pixels registered in the completed rounds.
+	Workers int     // Number of workers the render was started with.
+	States  []State // States of the workers.
+
+	R, G, B    histo.Histo // The red, green and blue histograms.
+	Importance histo.Histo // Histogram of sampled points and their importance.
+
+	// Options of the fractal which must be equal when resuming.
+	Width, Height int
+	Supersampling int
+	Tries         float64
+	Seed          int64
+	Sampler       fractal.Sampler
+}
+
+// LoadCheckpoint loads a previously saved checkpoint file.
+func LoadCheckpoint(filename string) (cp *Checkpoint, err error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	dec := gob.NewDecoder(file)
+	if err := dec.Decode(&cp); err != nil {
+		return nil, err
+	}
+	return cp, nil
+}
+
+// Save saves the checkpoint to a gob file. The file is replaced atomically, so
+// that an interrupted save doesn't destroy the previous checkpoint.
+func (cp *Checkpoint) Save(filename string) (err error) {
+	tmp := filename + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(file).Encode(cp); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
+
+// CheckpointError is the error of a checkpoint which couldn't be saved.
+type CheckpointError struct {
+	Err error
+}
+
+func (e *CheckpointError) Error() string {
+	return "checkpoint failed: " + e.Err.Error()
+}
+
+// Unwrap returns the error of saving the checkpoint.
+func (e *CheckpointError) Unwrap() error {
+	return e.Err
+}
+
+// save saves a checkpoint of the fractal and the states of the workers after
+// the given number of rounds.
+func save(frac *fractal.Fractal, round, totals int64, states []State) error {
+	cp := &Checkpoint{
+		Round:         round,
+		Totals:        totals,
+		Workers:       len(states),
+		States:        states,
+		R:             frac.R,
+		G:             frac.G,
+		B:             frac.B,
+		Importance:    frac.Importance,
+		Width:         frac.Width,
+		Height:        frac.Height,
+		Supersampling: frac.Supersampling,
+		Tries:         frac.Tries,
+		Seed:          frac.Seed,
+		Sampler:       frac.Sampler,
+	}
+	if err := cp.Save(frac.Checkpoint); err != nil {
+		return &CheckpointError{Err: err}
+	}
+	return nil
+}
+
+// restore restores the histograms of the fractal from the checkpoint.
+func (cp *Checkpoint) restore(frac *fractal.Fractal) error {
+	if cp.Width != frac.Width || cp.Height != frac.Height {
+		return fmt.Errorf("checkpoint dimensions %dx%d != %dx%d", cp.Width, cp.Height, frac.Width, frac.Height)
+	}
+	if cp.Supersampling != frac.Supersampling {
+		return fmt.Errorf("checkpoint supersampling %d != %d", cp.Supersampling, frac.Supersampling)
+	}
+	if cp.Tries != frac.Tries || cp.Seed != frac.Seed || cp.Sampler != frac.Sampler {
+		return fmt.Errorf("checkpoint was made with different sampling options")
+	}
+	frac.R, frac.G, frac.B = cp.R, cp.G, cp.B
+	if usesImportance(frac) {
+		frac.Importance = cp.Importance
+	}
+	return nil
+}
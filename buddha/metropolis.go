@@ -0,0 +1,161 @@
+package buddha
+
+import (
+	"context"
+	"math"
+	"math/cmplx"
+	"sync/atomic"
+
+	rand7i "github.com/7i/rand"
+
+	"github.com/karlek/wasabi/fractal"
+)
+
+const (
+	// Fraction of the tries which are sampled uniformly to estimate the mean
+	// contribution of an orbit before the mutations begin.
+	warmup = 0.1
+	// Probability of replacing the starting point with an entirely new one,
+	// instead of a small step away from it.
+	largeMutation = 0.2
+)
+
+// Chain is the state of the Markov chain of a metropolis worker, which is
+// continued across rounds and saved in checkpoints.
+type Chain struct {
+	Z, C   complex128 // Starting points of the current state.
+	Pixels int64      // Contribution of the current state, zero until a state is accepted.
+	Steps  int64      // Number of steps taken, including the warmup.
+
+	// Sum of contributions and number of uniformly sampled points, used to
+	// estimate the mean contribution.
+	Sum     float64
+	Uniform int64
+}
+
+// metropolis will try to find orbits in the complex function with the
+// Metropolis-Hastings algorithm. The starting point c is mutated from the
+// previously accepted point and the mutation is accepted with a probability
+// proportional to the number of orbit points inside the image. Each step
+// registers the current orbit weighted by the inverse of its contribution,
+// scaled by the mean contribution of uniformly sampled points, which keeps the
+// density of the histograms unbiased. The chain of the worker continues where
+// the previous round left it.
+func metropolis(ctx context.Context, frac *fractal.Fractal, w *worker, rng *rand7i.ComplexRNG, share int64, prog *counter) (total int64) {
+	// The orbit of the current state and the proposed mutation.
+	cur := fractal.NewOrbit(frac.Iterations)
+	next := fractal.NewOrbit(frac.Iterations)
+	zsrc, csrc := w.sources(frac, rng)
+	ch := &w.chain
+
+	// Recreate the orbit of the current state left by the previous round.
+	var curLength int64
+	if ch.Pixels > 0 {
+		cur.C = ch.C
+		curLength = orbitLength(ch.Z, ch.C, cur, frac)
+	}
+
+	var z, c complex128
+	var large bool
+	var i int64
+	for i = 0; i < share && ctx.Err() == nil; i, ch.Steps = i+1, ch.Steps+1 {
+		// Increase progress bar.
+		atomic.AddInt64(&prog.tries, 1)
+
+		// Sample uniformly until we have an estimate of the mean contribution
+		// and a starting point with at least one point inside the image.
+		if float64(ch.Steps) < warmup*float64(w.tries) || ch.Pixels == 0 {
+			c = frac.C(c, csrc)
+			z = frac.Z(c, zsrc)
+			next.C = c
+			next.Weight = 1
+
+			length := orbitLength(z, c, next, frac)
+			pixels := contribution(length, next, frac)
+			total += register(length, next, frac)
+			ch.Sum += float64(pixels)
+			ch.Uniform++
+			if pixels > 0 {
+				atomic.AddInt64(&prog.orbits, 1)
+				cur, next = next, cur
+				ch.Z, ch.C, ch.Pixels = z, c, pixels
+				curLength = length
+			}
+
+			// Plot sampling map.
+			if frac.PlotImportance {
+				importance(z, c, frac, pixels)
+			}
+			continue
+		}
+
+		c, large = mutate(ch.C, frac, rng, csrc)
+		z = frac.Z(c, zsrc)
+		next.C = c
+
+		// Mutations outside the domain of c have no density and are
+		// rejected.
+		var length, pixels int64
+		inside := frac.CDomain.Contains(c)
+		if inside {
+			length = orbitLength(z, c, next, frac)
+			pixels = contribution(length, next, frac)
+		}
+		// Large mutations are uniformly sampled and refines our estimate of the
+		// mean contribution.
+		if large {
+			ch.Sum += float64(pixels)
+			ch.Uniform++
+		}
+		// Accept the mutation with the probability of the ratio between the
+		// contributions. The mutations are symmetric, so the transition
+		// probabilities cancel out.
+		if inside && float64(pixels) >= float64(ch.Pixels)*unit(rng) {
+			cur, next = next, cur
+			ch.Z, ch.C, ch.Pixels = z, c, pixels
+			curLength = length
+		}
+
+		// Plot sampling map.
+		if frac.PlotImportance {
+			importance(ch.Z, ch.C, frac, ch.Pixels)
+		}
+
+		cur.Weight = ch.Sum / float64(ch.Uniform) / float64(ch.Pixels)
+		register(curLength, cur, frac)
+		total += ch.Pixels
+		atomic.AddInt64(&prog.orbits, 1)
+	}
+	return total
+}
+
+// mutate returns a new starting point from c. Most mutations are small steps
+// relative to the zoom level to explore the neighbourhood of a long orbit,
+// while some are chosen anew from the source to escape local maxima; large is
+// true for the latter.
+func mutate(c complex128, frac *fractal.Fractal, rng *rand7i.ComplexRNG, src fractal.Source) (_ complex128, large bool) {
+	if unit(rng) < largeMutation {
+		return frac.C(c, src), true
+	}
+	// Step length exponentially distributed between 1e-4 and 1e-4*e^4 of the
+	// view, in a random direction.
+	r := 4 / frac.Camera.Zoom() * 1e-4 * math.Exp(4*unit(rng))
+	phi := 2 * math.Pi * unit(rng)
+	return c + cmplx.Rect(r, phi), false
+}
+
+// contribution returns the number of points of the orbit which are inside the
+// image.
+func contribution(it int64, orbit *fractal.Orbit, frac *fractal.Fractal) (pixels int64) {
+	for _, p := range orbit.Points[:it] {
+		if _, ok := frac.Point(p, orbit.C); ok {
+			pixels++
+		}
+	}
+	return pixels
+}
+
+// unit returns a random number in the range [0, 1).
+func unit(rng *rand7i.ComplexRNG) float64 {
+	return (real(rng.Complex128Go()) + 2) / 4
+}
@@ -0,0 +1,35 @@
+package buddha
+
+import (
+	"math"
+
+	"github.com/karlek/wasabi/fractal"
+	"github.com/karlek/wasabi/histo"
+)
+
+// noise estimates the noise of the histograms of the fractal as their relative
+// change since the previous round, i.e. the L1 distance between the normalised
+// sums of the color channels, now and in prev. The distance is in the range
+// [0, 2] and infinite if either sum is empty. prev, of the dimensions of the
+// histograms, is updated with the current sums.
+func noise(prev histo.Histo, frac *fractal.Fractal) float64 {
+	r, g, b := frac.R.Pix, frac.G.Pix, frac.B.Pix
+	var prevTotal, total float64
+	for i, v := range prev.Pix {
+		prevTotal += v
+		total += r[i] + g[i] + b[i]
+	}
+
+	var dist float64
+	for i, v := range prev.Pix {
+		cur := r[i] + g[i] + b[i]
+		if prevTotal > 0 && total > 0 {
+			dist += math.Abs(cur/total - v/prevTotal)
+		}
+		prev.Pix[i] = cur
+	}
+	if prevTotal == 0 || total == 0 {
+		return math.Inf(1)
+	}
+	return dist
+}
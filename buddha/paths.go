@@ -64,7 +64,7 @@ func registerBezier(it int64, orbit *fractal.Orbit, frac *fractal.Fractal) (sum
 	for i := 0; i < int(it)-frac.BezierLevel; i++ {
 		var j int
 		for j = 0; j <= frac.BezierLevel; j++ {
-			p, ok := frac.Point(orbit.Points[i+j], orbit.C)
+			p, ok := frac.CellPoint(orbit.Points[i+j], orbit.C)
 			if !ok {
 				break
 			}
@@ -81,6 +81,7 @@ func registerBezier(it int64, orbit *fractal.Orbit, frac *fractal.Fractal) (sum
 		// }
 		last := orbit.Points[i+j]
 		red, green, blue := angleColor(frac, first, last)
+		red, green, blue = orbit.Weight*red, orbit.Weight*green, orbit.Weight*blue
 		for p := 0; p <= int(frac.PathPoints)-1; p++ {
 			t := float64(p) / float64(frac.PathPoints)
 			pt := bezier(points, frac.BezierLevel, t)
@@ -94,15 +95,16 @@ func registerBezier(it int64, orbit *fractal.Orbit, frac *fractal.Fractal) (sum
 
 func registerLinear(it int64, orbit *fractal.Orbit, frac *fractal.Fractal) int64 {
 	// Get color from gradient based on iteration count of the orbit.
-	red, green, blue := frac.Method.Get(it, frac.Iterations)
+	red, green, blue := frac.Method.Basin(orbit.Root).Get(it, frac.Iterations)
+	red, green, blue = orbit.Weight*red, orbit.Weight*green, orbit.Weight*blue
 	bresPoints := make([]image.Point, 0, frac.PathPoints)
 	for i := 0; i < int(it)-1; i++ {
-		// Convert the complex point to a pixel coordinate.
-		a, ok := frac.Point(orbit.Points[i], orbit.C)
+		// Convert the complex point to a cell of the histograms.
+		a, ok := frac.CellPoint(orbit.Points[i], orbit.C)
 		if !ok {
 			continue
 		}
-		b, ok := frac.Point(orbit.Points[i+1], orbit.C)
+		b, ok := frac.CellPoint(orbit.Points[i+1], orbit.C)
 		if !ok {
 			continue
 		}
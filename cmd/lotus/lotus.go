@@ -1,17 +1,20 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"image"
 	"image/jpeg"
 	_ "image/png"
 	"math"
+	"math/cmplx"
 	"os"
 	"sync"
 
 	"github.com/karlek/wasabi/fractal"
 	"github.com/karlek/wasabi/iro"
 	"github.com/karlek/wasabi/mandel"
+	"github.com/karlek/wasabi/prec"
 	"github.com/pkg/profile"
 	"github.com/sirupsen/logrus"
 )
@@ -34,17 +37,73 @@ func abs(z complex128) float64 {
 	return real(z)*real(z) + imag(z)*imag(z)
 }
 
-var white = iro.RGBA{0xff, 0xff, 0xff, 0xff}
+var white = iro.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
 
 const (
-	width      = 1024
-	height     = 1024
-	iterations = 15
+	width  = 1024
+	height = 1024
 )
 
+var (
+	// Number of iterations.
+	iterations int64
+	// Precision of the iterations: float64, double-double or big.
+	precision string
+	// Bits of the big precision.
+	bits uint
+	// Center of the render as decimals in full precision.
+	centerReal, centerImag string
+	// Zoom level around the center.
+	zoom float64
+	// Rotation of the camera around the center.
+	roll float64
+	// Name of the formula.
+	function string
+	// Iterate the pixels as perturbations of a reference orbit at the center.
+	perturb bool
+)
+
+func init() {
+	flag.Int64Var(&iterations, "iterations", 15, "number of iterations.")
+	flag.StringVar(&precision, "precision", "float64", "precision of deep zooms: float64, double-double or big.")
+	flag.UintVar(&bits, "bits", 0, "bits of the big precision, defaults to enough bits for the zoom.")
+	flag.StringVar(&centerReal, "real", "0", "real value of the center.")
+	flag.StringVar(&centerImag, "imag", "0", "imaginary value of the center.")
+	flag.Float64Var(&zoom, "zoom", 1, "zoom level around the center.")
+	flag.Float64Var(&roll, "roll", 0, "rotation in radians of the camera around the center.")
+	flag.StringVar(&function, "function", "mandelbrot", "formula of the fractal.")
+	flag.BoolVar(&perturb, "perturb", false, "iterate the pixels as perturbations of a reference orbit at the center.")
+}
+
 func main() {
+	flag.Parse()
 	defer profile.Start().Stop()
 
+	tier, err := prec.ParseTier(precision)
+	if err != nil {
+		logrus.Fatalln(err)
+	}
+	if tier == prec.Big && bits == 0 {
+		bits = prec.ZoomBits(zoom)
+	}
+	// The center is the origin of the points, which are iterated in the
+	// precision tier.
+	origin, err := prec.Parse(tier, centerReal, centerImag, bits)
+	if err != nil {
+		logrus.Fatalln(err)
+	}
+
+	formula, err := mandel.LookupFormula(function)
+	if err != nil {
+		logrus.Fatalln(err)
+	}
+	if formula.Prec == nil && tier != prec.Float64 {
+		logrus.Fatalf("formula %q has no precision %v", formula.Name, tier)
+	}
+	if formula.Perturb == nil && perturb {
+		logrus.Fatalf("formula %q has no perturbation", formula.Name)
+	}
+
 	ranges := []float64{}
 	for i := range iro.Viridis {
 		ranges = append(ranges, float64(i)/float64(len(iro.Viridis)))
@@ -60,28 +119,26 @@ func main() {
 	// for frame = 1; frame < 3*max; frame++ {
 	img := image.NewRGBA(image.Rect(0, 0, width, height))
 
-	frac := fractal.New(
-		width,
-		height,
-		iterations,
-		nil,
-		complex(0, 0),
-		4e0,
-		fractal.Crci,
-		nil,
-		1,
-		complex(0, 0),
-		false,
-		1,
-		0,
-		0,
-		0,
-		nil,
-		0,
-		nil, nil,
-		0)
-
-	frac.Func = mandel.Test
+	frac, err := fractal.FromConfig(fractal.Config{
+		Width:      width,
+		Height:     height,
+		Iterations: iterations,
+		Bailout:    4e0,
+		Plane:      fractal.Crci,
+		Func:       formula.Func,
+		Coef:       1,
+		Prec:       formula.Prec,
+		Register:   mandel.Escaped,
+		Seed:       1,
+		Zoom:       zoom,
+		Roll:       roll,
+		Precision:  tier,
+		Bits:       bits,
+		Origin:     origin,
+	})
+	if err != nil {
+		logrus.Fatalln(err)
+	}
 	// frac.Func = func(z, c, _ complex128) complex128 {
 	// 	// return z*z + c
 	// 	// Burning-ship
@@ -98,6 +155,17 @@ func main() {
 	// 		log.Fatalln(err)
 	// 	}
 
+	// The pixels are perturbations of the reference orbit at the center,
+	// which is the only orbit iterated in the precision tier.
+	var ref *mandel.Reference
+	if perturb {
+		ref = mandel.NewReference(origin, formula.Perturb, frac)
+		if formula.Name == "mandelbrot" {
+			radius := cmplx.Abs(frac.ImageToComplex(0, 0))
+			logrus.Printf("series approximation skips %d iterations", ref.Approximate(radius))
+		}
+	}
+
 	wg := new(sync.WaitGroup)
 	wg.Add(width)
 
@@ -129,10 +197,17 @@ func main() {
 	for j := 0; j < height; j++ {
 		go func(j int, frac *fractal.Fractal, img *image.RGBA, wg *sync.WaitGroup) {
 			for i := 0; i < width; i++ {
-				c := frac.ImageToComplex(i, j)
-
 				// last, escapesIn := mandel.FieldLinesEscapes(z, c, frac, 1e+1)
-				last, escapesIn := mandel.EscapedLast(z, c, frac)
+				var last complex128
+				var escapesIn int64
+				switch {
+				case ref != nil:
+					last, escapesIn = ref.EscapedLast(frac.ImageToComplex(i, j), frac)
+				case tier == prec.Float64:
+					last, escapesIn = mandel.EscapedLast(z, frac.ImageToPrec(i, j).Complex128(), frac)
+				default:
+					last, escapesIn = mandel.EscapedLastPrec(prec.New(tier, z, bits), frac.ImageToPrec(i, j), frac)
+				}
 				// _, closest := mandel.OrbitTrap(z, c, frac, mandel.Pickover(complex(-0.5, 0.0)))
 				// last = closest
 				if escapesIn == -1 {
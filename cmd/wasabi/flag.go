@@ -4,6 +4,9 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/karlek/wasabi/coloring"
@@ -40,6 +43,8 @@ var (
 	trapPath string
 	// Should we load the previous color channels?
 	load bool
+	// Path to a checkpoint to resume the render from.
+	resume string
 	// Should we save our r/g/b channels?
 	save bool
 	// Should we calculate the anti-buddhabrot instead?
@@ -87,6 +92,9 @@ var (
 	crciFlag bool
 	crziFlag bool
 	ziciFlag bool
+	zicrFlag bool
+	// Projection matrix of the 4D points, overrides the capital planes.
+	projection string
 
 	// Should we plot the importance map?
 	importanceMap bool
@@ -115,10 +123,14 @@ var (
 	theta float64
 
 	mergeFlag bool
+
+	// List the formulas of the catalogue.
+	listFunctions bool
 )
 
 func init() {
 	flag.BoolVar(&mergeFlag, "merge", false, "merge histograms")
+	flag.BoolVar(&listFunctions, "list-functions", false, "list the complex functions which can be used in blueprints.")
 	flag.BoolVar(&load, "load", false, "use pre-computed values.")
 	flag.BoolVar(&silent, "silent", false, "no output")
 	flag.BoolVar(&save, "save", false, "save orbits.")
@@ -133,6 +145,8 @@ func init() {
 	flag.BoolVar(&crciFlag, "crci", false, "Render the Cr, Ci capital plane.")
 	flag.BoolVar(&crziFlag, "crzi", false, "Render the Cr, Zi capital plane.")
 	flag.BoolVar(&ziciFlag, "zici", false, "Render the Zi, Ci capital plane.")
+	flag.BoolVar(&zicrFlag, "zicr", false, "Render the Zi, Cr capital plane.")
+	flag.StringVar(&projection, "projection", "", "comma separated 2x4 projection matrix of (Zr, Zi, Cr, Ci), e.g. \"1,0,0,0,0,1,0,0\" for Zr, Zi.")
 	flag.BoolVar(&importanceMap, "important", false, "Render importance sampling map.")
 	flag.BoolVar(&interactive, "interactive", false, "Live interactive rendering")
 	flag.StringVar(&fun, "function", "exp", "color scaling function")
@@ -140,8 +154,9 @@ func init() {
 	flag.StringVar(&out, "out", "a", "output filename. Image file type will be suffixed.")
 	flag.StringVar(&palettePath, "palette", "", "path to image to be used as color palette")
 	flag.StringVar(&trapPath, "trap", "", "orbit trap path to image.")
+	flag.StringVar(&resume, "resume", "", "resume the render from a checkpoint file, which is suffixed with the frame number for the frames of a sweep.")
 	flag.Float64Var(&tries, "tries", 1e0, "number (width*height) of orbits attempts")
-	flag.Float64Var(&theta, "theta", 0, "rotation angle in radian")
+	flag.Float64Var(&theta, "theta", 0, "rotation angle in radian of the ZrCr plane.")
 	flag.Float64Var(&realCoefficient, "realco", 1, "real coefficient for the complex function.")
 	flag.Float64Var(&imagCoefficient, "imagco", 0, "imag coefficient for the complex function.")
 	flag.Float64Var(&bailout, "bail", 4, "bailout value")
@@ -166,6 +181,16 @@ func usage() {
 	flag.PrintDefaults()
 }
 
+// printFunctions prints the formulas of the catalogue.
+func printFunctions() {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tBAILOUT\tFORMULA")
+	for _, f := range mandel.Formulas() {
+		fmt.Fprintf(w, "%s\t%g\t%s\n", f.Name, f.Bailout, f.Description)
+	}
+	w.Flush()
+}
+
 // parseModeFlag parses the _mode_ string to a coloring function.
 func parseModeFlag() {
 	switch modeStr {
@@ -214,38 +239,59 @@ func parseAdvancedFlags() {
 	parseFunctionFlag()
 	parseModeFlag()
 
-	// Save the point.
+	// Choose the plane, otherwise the plane of the blueprint is used.
+	plane = flagPlane()
+
+	// Create our complex type from two float values.
+	offset = complex(offsetReal, offsetImag)
+	coefficient = complex(realCoefficient, imagCoefficient)
+	iterations = int64(iterationsFlag)
+}
+
+// flagPlane returns the plane chosen by the flags, or nil if the plane of the
+// blueprint is used.
+func flagPlane() func(complex128, complex128) complex128 {
 	switch {
+	case projection != "":
+		return parseProjection(projection)
 	case zrziFlag:
 		// Original.
-		plane = zrzi
+		return fractal.Zrzi
 	case zrcrFlag:
 		// Pretty :D
-		plane = zrcr
+		return fractal.Zrcr
 	case zrciFlag:
 		// Pretty :D
-		plane = zrci
+		return fractal.Zrci
 	case crciFlag:
 		// Mandelbrot perimiter.
-		plane = crci
+		return fractal.Crci
 	case crziFlag:
 		// Pretty :D
-		plane = crzi
+		return fractal.Crzi
 	case ziciFlag:
 		// Pretty :D
-		plane = zici
-	default:
-		plane = zrzi
+		return fractal.Zici
+	case zicrFlag:
+		// Pretty :D
+		return fractal.Zicr
 	}
-	// Create our complex type from two float values.
-	offset = complex(offsetReal, offsetImag)
-	coefficient = complex(realCoefficient, imagCoefficient)
-	iterations = int64(iterationsFlag)
+	return nil
 }
 
-func zrzi(z, c complex128) complex128 { return complex(real(z), imag(z)) }
-func zrcr(z, c complex128) complex128 { return complex(real(z), real(c)) }
-func zrci(z, c complex128) complex128 { return complex(real(z), imag(c)) }
-func crci(z, c complex128) complex128 { return complex(real(c), imag(c)) }
-func crzi(z, c complex128) complex128 { return complex(real(c), imag(z)) }
-func zici(z, c complex128) complex128 { return complex(imag(z), imag(c)) }
+// parseProjection parses the _projection_ string to a plane function.
+func parseProjection(str string) func(complex128, complex128) complex128 {
+	fields := strings.Split(str, ",")
+	if len(fields) != 8 {
+		logrus.Fatalln("invalid projection, expected 8 values:", str)
+	}
+	var p fractal.Projection
+	for i, field := range fields {
+		v, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			logrus.Fatalln("invalid projection:", err)
+		}
+		p[i/4][i%4] = v
+	}
+	return p.Plane()
+}
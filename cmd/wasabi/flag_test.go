@@ -0,0 +1,26 @@
+package main
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/karlek/wasabi/fractal"
+)
+
+func TestFlagPlane(t *testing.T) {
+	z, c := complex(1, 2), complex(3, 4)
+	for _, name := range []string{"crci", "crzi", "zici", "zicr", "zrci", "zrcr", "zrzi"} {
+		want, _ := fractal.NamedPlane(name)
+		if err := flag.Set(name, "true"); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		got := flagPlane()
+		flag.Set(name, "false")
+		if got == nil || got(z, c) != want(z, c) {
+			t.Errorf("-%s: expected the plane %s", name, name)
+		}
+	}
+	if flagPlane() != nil {
+		t.Errorf("expected the plane of the blueprint without plane flags")
+	}
+}
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"fmt"
 	"image"
 	"image/draw"
 	"runtime"
@@ -14,13 +13,13 @@ import (
 	"github.com/karlek/wasabi/fractal"
 	"github.com/karlek/wasabi/plot"
 	"github.com/karlek/wasabi/render"
+	"github.com/sirupsen/logrus"
 )
 
 func makeFrame(ren *render.Render, frac *fractal.Fractal) *pixel.PictureData {
 	ren.OrbitRatio = buddha.FillHistograms(frac, runtime.NumCPU())
 	plot.Plot(ren, frac)
-	fmt.Println(frac.Theta)
-	fmt.Println(frac.Theta2)
+	logrus.Debugf("rotation: %+v", frac.Rotation())
 	return pixel.PictureDataFromImage(ren.Image)
 }
 
@@ -78,27 +77,35 @@ func renderRun() {
 func keyListener(win *pixelgl.Window, pic *pixel.PictureData, sprite *pixel.Sprite, ren *render.Render, frac *fractal.Fractal) {
 	render := false
 	if win.Pressed(pixelgl.KeyA) {
-		(*frac).Zoom -= 0.1
+		frac.Camera.Scale /= 1.1
 		render = true
 	}
 	if win.Pressed(pixelgl.KeyS) {
-		(*frac).Zoom += 0.1
+		frac.Camera.Scale *= 1.1
 		render = true
 	}
 	if win.Pressed(pixelgl.KeyU) {
-		(*frac).Theta2 -= 0.1
+		r := frac.Rotation()
+		r.ZiCi -= 0.1
+		frac.Rotate(r)
 		render = true
 	}
 	if win.Pressed(pixelgl.KeyI) {
-		(*frac).Theta2 += 0.1
+		r := frac.Rotation()
+		r.ZiCi += 0.1
+		frac.Rotate(r)
 		render = true
 	}
 	if win.Pressed(pixelgl.KeyJ) {
-		(*frac).Theta -= 0.1
+		r := frac.Rotation()
+		r.ZrCr -= 0.1
+		frac.Rotate(r)
 		render = true
 	}
 	if win.Pressed(pixelgl.KeyK) {
-		(*frac).Theta += 0.1
+		r := frac.Rotation()
+		r.ZrCr += 0.1
+		frac.Rotate(r)
 		render = true
 	}
 	if win.Pressed(pixelgl.KeyQ) {
@@ -146,4 +153,5 @@ var planes = []func(complex128, complex128) complex128{
 	fractal.Zicr,
 	fractal.Zici,
 	fractal.Crci,
+	fractal.Crzi,
 }
@@ -2,14 +2,29 @@ package main
 
 import (
 	"encoding/gob"
-	"image"
+	"fmt"
 	"os"
 
+	"github.com/karlek/wasabi/buddha"
 	"github.com/karlek/wasabi/fractal"
-	"github.com/karlek/wasabi/iro"
-	colorful "github.com/lucasb-eyer/go-colorful"
+	"github.com/karlek/wasabi/histo"
 )
 
+// artVersion is the version of the format of the cached art, which is saved
+// before the art.
+const artVersion = 1
+
+// art is the part of a fractal which is cached: the histograms and the options
+// which plotting them depends on. The other options of the fractal, such as
+// its domains and precision, are given by the blueprint.
+type art struct {
+	Width, Height  int
+	Supersampling  int
+	R, G, B        histo.Histo
+	Importance     histo.Histo
+	PlotImportance bool
+}
+
 func saveArt(frac *fractal.Fractal) (err error) {
 	file, err := os.Create("r-g-b.gob")
 	if err != nil {
@@ -17,10 +32,19 @@ func saveArt(frac *fractal.Fractal) (err error) {
 	}
 	defer file.Close()
 	enc := gob.NewEncoder(file)
-	gob.Register(colorful.Color{})
-	gob.Register(&image.YCbCr{})
-	gob.Register(iro.RGBA{})
-	err = enc.Encode(frac)
+	if err := enc.Encode(artVersion); err != nil {
+		return err
+	}
+	err = enc.Encode(art{
+		Width:          frac.Width,
+		Height:         frac.Height,
+		Supersampling:  frac.Supersampling,
+		R:              frac.R,
+		G:              frac.G,
+		B:              frac.B,
+		Importance:     frac.Importance,
+		PlotImportance: frac.PlotImportance,
+	})
 	if err != nil {
 		return err
 	}
@@ -33,17 +57,40 @@ func loadHistogram(filename string) (frac *fractal.Fractal, err error) {
 		return nil, err
 	}
 	defer file.Close()
-	gob.Register(colorful.Color{})
-	gob.Register(image.YCbCr{})
-	gob.Register(iro.RGBA{})
+	var a art
 	dec := gob.NewDecoder(file)
-	if err := dec.Decode(&frac); err != nil {
+	if err := histo.CheckVersion(dec, artVersion); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	if err := dec.Decode(&a); err != nil {
 		return nil, err
 	}
-
-	return frac, nil
+	return &fractal.Fractal{
+		Width:          a.Width,
+		Height:         a.Height,
+		Supersampling:  a.Supersampling,
+		R:              a.R,
+		G:              a.G,
+		B:              a.B,
+		Importance:     a.Importance,
+		PlotImportance: a.PlotImportance,
+	}, nil
 }
 
 func loadArt() (frac *fractal.Fractal, err error) {
 	return loadHistogram("r-g-b.gob")
 }
+
+// loadCheckpoint loads the checkpoint file to resume the render from.
+func loadCheckpoint(frac *fractal.Fractal, filename string) (*buddha.Checkpoint, error) {
+	cp, err := buddha.LoadCheckpoint(filename)
+	if err != nil {
+		return nil, err
+	}
+	// Keep saving checkpoints to the same file, unless the blueprint says
+	// otherwise.
+	if frac.Checkpoint == "" {
+		frac.Checkpoint = filename
+	}
+	return cp, nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/gob"
+	"errors"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/karlek/wasabi/fractal"
+	"github.com/karlek/wasabi/histo"
+	"github.com/karlek/wasabi/mandel"
+	"github.com/karlek/wasabi/prec"
+)
+
+func TestSaveArt(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wasabi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	// The domains and the origin of the precision tier are interfaces, which
+	// are left to the blueprint.
+	disc := fractal.Disc{Center: -0.5, Radius: 1}
+	frac, err := fractal.FromConfig(fractal.Config{
+		Width:          6,
+		Height:         4,
+		Iterations:     10,
+		Bailout:        4,
+		Func:           mandel.Mandelbrot,
+		Prec:           mandel.MandelbrotPrec,
+		Coef:           1,
+		Register:       mandel.Escaped,
+		C:              fractal.InDomain(disc),
+		CDomain:        disc,
+		Precision:      prec.DoubleDouble,
+		Supersampling:  2,
+		PlotImportance: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The pre-pass grid isn't part of the art.
+	frac.Grid = fractal.NewGrid(frac, 8)
+	for i := range frac.R.Pix {
+		frac.R.Pix[i], frac.G.Pix[i], frac.B.Pix[i] = float64(i), float64(2*i), float64(3*i)
+	}
+	frac.Importance.Add(1, 2, 5)
+
+	if err := saveArt(frac); err != nil {
+		t.Fatal(err)
+	}
+	got, err := loadArt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Width != frac.Width || got.Height != frac.Height || got.Supersampling != frac.Supersampling || !got.PlotImportance {
+		t.Errorf("expected the options of the saved fractal, got %dx%d supersampled %d", got.Width, got.Height, got.Supersampling)
+	}
+	if !reflect.DeepEqual(got.R, frac.R) || !reflect.DeepEqual(got.G, frac.G) || !reflect.DeepEqual(got.B, frac.B) || !reflect.DeepEqual(got.Importance, frac.Importance) {
+		t.Error("loaded histograms differ from the saved histograms")
+	}
+
+	// Caches of the columns which preceded the flat histograms.
+	file, err := os.Create("r-g-b.gob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := struct{ R, G, B [][]float64 }{R: [][]float64{{1, 2}}}
+	if err := gob.NewEncoder(file).Encode(old); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	if _, err := loadArt(); !errors.Is(err, histo.ErrVersion) {
+		t.Errorf("expected an error of an older format, got %v", err)
+	}
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"image"
@@ -24,10 +26,11 @@ import (
 )
 
 func main() {
-	defer profile.Start(profile.CPUProfile).Stop()
+	prof := profile.Start(profile.CPUProfile, profile.NoShutdownHook)
+	defer prof.Stop()
 
 	// Handle interrupts as fails, so we can chain with an image viewer.
-	handleInterrupts()
+	ctx := handleInterrupts()
 
 	// Parse flag and demand blueprint file.
 	handleFlags()
@@ -43,16 +46,27 @@ func main() {
 		err = merge(flag.Args())
 	default:
 		// Render blueprint.
-		err = renderBuddha(flag.Arg(0))
+		err = renderBuddha(ctx, flag.Arg(0))
 	}
 	if err != nil {
 		logrus.Warnln(err)
 	}
+	// An interrupted render fails, and so does a render whose checkpoint was
+	// lost, even if the checkpoint of the interruption failed.
+	var cerr *buddha.CheckpointError
+	if ctx.Err() != nil || errors.As(err, &cerr) {
+		prof.Stop()
+		os.Exit(1)
+	}
 }
 
 // Parse flag and demand blueprint file.
 func handleFlags() {
 	flag.Parse()
+	if listFunctions {
+		printFunctions()
+		os.Exit(0)
+	}
 	parseFunctionFlag()
 	if flag.NArg() < 1 {
 		usage()
@@ -64,14 +78,21 @@ func handleFlags() {
 	}
 }
 
-// Handle interrupts as fails, so we can chain with an image viewer.
-func handleInterrupts() {
-	inter := make(chan os.Signal, 1)
+// Handle interrupts as fails, so we can chain with an image viewer. The
+// returned context is cancelled by the first interrupt, which stops the render
+// after a final checkpoint; the second interrupt exits immediately.
+func handleInterrupts() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	inter := make(chan os.Signal, 2)
 	signal.Notify(inter, os.Interrupt)
 	go func(inter chan os.Signal) {
+		<-inter
+		logrus.Warnln("[!] Interrupted, stopping the render.")
+		cancel()
 		<-inter
 		os.Exit(1)
 	}(inter)
+	return ctx
 }
 
 func initialize(blueprintPath string) (frac *fractal.Fractal, ren *render.Render, blue *blueprint.Blueprint, err error) {
@@ -85,7 +106,14 @@ func initialize(blueprintPath string) (frac *fractal.Fractal, ren *render.Render
 }
 
 func readFlags(frac *fractal.Fractal, ren *render.Render) {
-	frac.Theta = theta
+	if plane != nil {
+		frac.Plane = plane
+	}
+	if theta != 0 {
+		r := frac.Rotation()
+		r.ZrCr = theta
+		frac.Rotate(r)
+	}
 	ren.F = f
 	ren.Exposure = exposure
 	if factor != -1 {
@@ -93,12 +121,39 @@ func readFlags(frac *fractal.Fractal, ren *render.Render) {
 	}
 }
 
-func renderBuddha(blueprintPath string) (err error) {
+func renderBuddha(ctx context.Context, blueprintPath string) (err error) {
 	logrus.Infoln("[.] Initializing.")
-	frac, ren, blue, err := initialize(blueprintPath)
+	blue, err := blueprint.Parse(blueprintPath)
 	if err != nil {
 		return err
 	}
+	if blue.Frames <= 1 {
+		return renderBlueprint(ctx, blue, out, resume)
+	}
+	// Sweep the julia constant over the frames.
+	for i := 0; i < blue.Frames; i++ {
+		logrus.Infof("[.] Rendering frame %d/%d.", i+1, blue.Frames)
+		// Each frame is resumed from a checkpoint of its own, and frames which
+		// weren't checkpointed start from the beginning.
+		var cp string
+		if resume != "" {
+			cp = blueprint.FramePath(resume, i)
+			if _, err := os.Stat(cp); os.IsNotExist(err) {
+				cp = ""
+			}
+		}
+		if err := renderBlueprint(ctx, blue.Frame(i), blueprint.FramePath(out, i), cp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderBlueprint renders the blueprint to the output filename, resumed from
+// the checkpoint file resume unless it's empty.
+func renderBlueprint(ctx context.Context, blue *blueprint.Blueprint, out, resume string) (err error) {
+	frac, ren := blue.Fractal(), blue.Render()
+	draw.Draw(ren.Image, ren.Image.Bounds(), &image.Uniform{blue.BaseColor.StandardRGBA()}, image.ZP, draw.Src)
 	readFlags(frac, ren)
 
 	if load {
@@ -108,7 +163,19 @@ func renderBuddha(blueprintPath string) (err error) {
 			return err
 		}
 	} else {
-		ren.OrbitRatio = buddha.FillHistograms(frac, runtime.NumCPU())
+		var cp *buddha.Checkpoint
+		if resume != "" {
+			logrus.Infoln("[-] Resuming from checkpoint.")
+			if cp, err = loadCheckpoint(frac, resume); err != nil {
+				return err
+			}
+		}
+		res, err := buddha.ResumeHistograms(ctx, frac, runtime.NumCPU(), cp)
+		if err != nil {
+			return err
+		}
+		ren.OrbitRatio = res.OrbitRatio
+		logrus.Infoln("[i] Noise", res.Noise)
 		if histo.Max(frac.R)+histo.Max(frac.G)+histo.Max(frac.B) == 0 {
 			out += "-black"
 			return fmt.Errorf("black")
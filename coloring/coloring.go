@@ -13,6 +13,9 @@ import (
 type Coloring struct {
 	Grad iro.Gradient
 	mode Mode
+
+	// Colorings of the basins of the roots of root-finding fractals.
+	basins []*Coloring
 }
 
 // Mode returns the coloring mode.
@@ -35,6 +38,22 @@ func NewColoring(base iro.Color, mode Mode, colors []iro.Color, stops []float64)
 	return &Coloring{Grad: grad, mode: mode}
 }
 
+// AddBasin pre-calculates the gradient of the next basin, which the orbits
+// converging to the root of the same index are colored with.
+func (c *Coloring) AddBasin(colors []iro.Color, stops []float64) {
+	c.basins = append(c.basins, NewColoring(c.Grad.Base, c.mode, colors, stops))
+}
+
+// Basin returns the coloring of the basin of the root with the given index.
+// The basins are repeated if there are more roots than basins, and without
+// basins the coloring itself is returned.
+func (c *Coloring) Basin(root int) *Coloring {
+	if len(c.basins) == 0 || root < 0 {
+		return c
+	}
+	return c.basins[root%len(c.basins)]
+}
+
 // Get returns red, green and blue values from the current iteration i and the max iteration it.
 func (c *Coloring) Get(i int64, it int64) (float64, float64, float64) {
 	switch c.mode {
@@ -48,6 +67,8 @@ func (c *Coloring) Get(i int64, it int64) (float64, float64, float64) {
 		return c.iteration(i, it)
 	case Path:
 		return c.vector(i, it)
+	case Nebula:
+		return 1, 1, 1
 	default:
 		return c.modulo(i)
 	}
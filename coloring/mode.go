@@ -14,6 +14,9 @@ const (
 	VectorField
 	// Path linearly interpolates between the points in the path.
 	Path
+	// Nebula registers the orbits in the channels whose iteration limits they
+	// escape under, see fractal.Nebula.
+	Nebula
 )
 
 func (m Mode) String() string {
@@ -28,6 +31,8 @@ func (m Mode) String() string {
 		return "OrbitLength"
 	case Path:
 		return "Path"
+	case Nebula:
+		return "Nebula"
 	default:
 		return "fail"
 	}
@@ -0,0 +1,407 @@
+// Package expr implements an expression language for complex functions, which
+// are compiled to a tree of closures.
+//
+// An expression is a function of the variables z, c and coef, e.g.
+// "z^3 + c*sin(z)" or "conj(z)^2 + coef*c". The expression supports the
+// operators + - * / and ^, parentheses, real and imaginary number literals
+// such as 2.5 and 3i, and the functions:
+//
+//	sin, cos, tan, sinh, cosh, tanh, asin, acos, atan, exp, log, sqrt
+//	conj          complex conjugate
+//	abs           absolute value of both components
+//	absre, absim  absolute value of the real or imaginary component
+//	re, im        real or imaginary component as a real number
+//	mod, arg      modulus and argument as real numbers
+//	pow(x, y)     x to the power of y, same as x^y
+//
+// Powers with constant integer exponents are computed by repeated
+// multiplication, which is both faster and more accurate than cmplx.Pow.
+package expr
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Func is a complex function of the point z, the constant c and the
+// coefficient coef.
+type Func func(z, c, coef complex128) complex128
+
+// node is a compiled subexpression. Constant nodes are folded while parsing.
+type node struct {
+	f     Func
+	isVal bool       // The node is a constant.
+	val   complex128 // Value of the constant.
+}
+
+// constant returns a constant node.
+func constant(v complex128) node {
+	return node{f: func(_, _, _ complex128) complex128 { return v }, isVal: true, val: v}
+}
+
+// Compile parses the expression and returns its complex function.
+func Compile(src string) (Func, error) {
+	p := new(parser)
+	if err := p.lex(src); err != nil {
+		return nil, err
+	}
+	n, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != eof {
+		return nil, fmt.Errorf("unexpected %q at position %d", t.text, t.pos)
+	}
+	return n.f, nil
+}
+
+// MustCompile is like Compile but panics if the expression is invalid.
+func MustCompile(src string) Func {
+	f, err := Compile(src)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+// Kinds of tokens.
+const (
+	eof = iota
+	number
+	imaginary
+	ident
+	operator
+)
+
+// token is a lexical token of an expression.
+type token struct {
+	kind int
+	text string
+	pos  int
+}
+
+// parser is a recursive descent parser of expressions.
+type parser struct {
+	toks []token
+	i    int
+}
+
+// lex splits the expression into tokens.
+func (p *parser) lex(src string) error {
+	for i := 0; i < len(src); {
+		r := rune(src[i])
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || r == '.':
+			j := i
+			for j < len(src) && (unicode.IsDigit(rune(src[j])) || src[j] == '.') {
+				j++
+			}
+			// Exponent of the number, e.g. 1e-3.
+			if j < len(src) && (src[j] == 'e' || src[j] == 'E') {
+				k := j + 1
+				if k < len(src) && (src[k] == '+' || src[k] == '-') {
+					k++
+				}
+				if k < len(src) && unicode.IsDigit(rune(src[k])) {
+					for j = k; j < len(src) && unicode.IsDigit(rune(src[j])); j++ {
+					}
+				}
+			}
+			kind := number
+			text := src[i:j]
+			if j < len(src) && src[j] == 'i' && (j+1 == len(src) || !isIdent(rune(src[j+1]))) {
+				kind = imaginary
+				j++
+			}
+			p.toks = append(p.toks, token{kind: kind, text: text, pos: i})
+			i = j
+		case isIdent(r):
+			j := i
+			for j < len(src) && (isIdent(rune(src[j])) || unicode.IsDigit(rune(src[j]))) {
+				j++
+			}
+			p.toks = append(p.toks, token{kind: ident, text: strings.ToLower(src[i:j]), pos: i})
+			i = j
+		case strings.ContainsRune("+-*/^(),", r):
+			p.toks = append(p.toks, token{kind: operator, text: string(r), pos: i})
+			i++
+		default:
+			return fmt.Errorf("unexpected %q at position %d", r, i)
+		}
+	}
+	p.toks = append(p.toks, token{kind: eof, text: "end of expression", pos: len(src)})
+	return nil
+}
+
+// isIdent returns true if r may start an identifier.
+func isIdent(r rune) bool {
+	return unicode.IsLetter(r) || r == '_'
+}
+
+// peek returns the current token.
+func (p *parser) peek() token {
+	return p.toks[p.i]
+}
+
+// accept consumes the current token if it is the operator op.
+func (p *parser) accept(op string) bool {
+	if t := p.peek(); t.kind == operator && t.text == op {
+		p.i++
+		return true
+	}
+	return false
+}
+
+// expect consumes the operator op or returns an error.
+func (p *parser) expect(op string) error {
+	if !p.accept(op) {
+		t := p.peek()
+		return fmt.Errorf("expected %q, got %q at position %d", op, t.text, t.pos)
+	}
+	return nil
+}
+
+// expr parses a sum of terms.
+//
+//	expr = term {("+" | "-") term}
+func (p *parser) expr() (node, error) {
+	n, err := p.term()
+	if err != nil {
+		return n, err
+	}
+	for {
+		var op func(a, b complex128) complex128
+		switch {
+		case p.accept("+"):
+			op = func(a, b complex128) complex128 { return a + b }
+		case p.accept("-"):
+			op = func(a, b complex128) complex128 { return a - b }
+		default:
+			return n, nil
+		}
+		m, err := p.term()
+		if err != nil {
+			return m, err
+		}
+		n = binary(n, m, op)
+	}
+}
+
+// term parses a product of factors.
+//
+//	term = unary {("*" | "/") unary}
+func (p *parser) term() (node, error) {
+	n, err := p.unary()
+	if err != nil {
+		return n, err
+	}
+	for {
+		var op func(a, b complex128) complex128
+		switch {
+		case p.accept("*"):
+			op = func(a, b complex128) complex128 { return a * b }
+		case p.accept("/"):
+			op = func(a, b complex128) complex128 { return a / b }
+		default:
+			return n, nil
+		}
+		m, err := p.unary()
+		if err != nil {
+			return m, err
+		}
+		n = binary(n, m, op)
+	}
+}
+
+// unary parses a negation.
+//
+//	unary = "-" unary | "+" unary | power
+func (p *parser) unary() (node, error) {
+	switch {
+	case p.accept("-"):
+		n, err := p.unary()
+		if err != nil {
+			return n, err
+		}
+		return apply(n, func(a complex128) complex128 { return -a }), nil
+	case p.accept("+"):
+		return p.unary()
+	}
+	return p.power()
+}
+
+// power parses a right associative power.
+//
+//	power = primary ["^" unary]
+func (p *parser) power() (node, error) {
+	n, err := p.primary()
+	if err != nil {
+		return n, err
+	}
+	if !p.accept("^") {
+		return n, nil
+	}
+	m, err := p.unary()
+	if err != nil {
+		return m, err
+	}
+	return pow(n, m), nil
+}
+
+// primary parses numbers, variables, function calls and parentheses.
+//
+//	primary = number | variable | function "(" expr {"," expr} ")" | "(" expr ")"
+func (p *parser) primary() (node, error) {
+	t := p.peek()
+	switch t.kind {
+	case number, imaginary:
+		p.i++
+		v, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return node{}, fmt.Errorf("invalid number %q at position %d", t.text, t.pos)
+		}
+		if t.kind == imaginary {
+			return constant(complex(0, v)), nil
+		}
+		return constant(complex(v, 0)), nil
+	case ident:
+		p.i++
+		if !p.accept("(") {
+			return variable(t)
+		}
+		var args []node
+		for {
+			n, err := p.expr()
+			if err != nil {
+				return n, err
+			}
+			args = append(args, n)
+			if !p.accept(",") {
+				break
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return node{}, err
+		}
+		return call(t, args)
+	case operator:
+		if p.accept("(") {
+			n, err := p.expr()
+			if err != nil {
+				return n, err
+			}
+			return n, p.expect(")")
+		}
+	}
+	return node{}, fmt.Errorf("unexpected %q at position %d", t.text, t.pos)
+}
+
+// variable returns the node of a variable or a named constant.
+func variable(t token) (node, error) {
+	switch t.text {
+	case "z":
+		return node{f: func(z, _, _ complex128) complex128 { return z }}, nil
+	case "c":
+		return node{f: func(_, c, _ complex128) complex128 { return c }}, nil
+	case "coef":
+		return node{f: func(_, _, coef complex128) complex128 { return coef }}, nil
+	case "i":
+		return constant(1i), nil
+	case "pi":
+		return constant(math.Pi), nil
+	case "e":
+		return constant(math.E), nil
+	}
+	return node{}, fmt.Errorf("unknown variable %q at position %d", t.text, t.pos)
+}
+
+// functions are the functions of one argument.
+var functions = map[string]func(complex128) complex128{
+	"sin":   cmplx.Sin,
+	"cos":   cmplx.Cos,
+	"tan":   cmplx.Tan,
+	"sinh":  cmplx.Sinh,
+	"cosh":  cmplx.Cosh,
+	"tanh":  cmplx.Tanh,
+	"asin":  cmplx.Asin,
+	"acos":  cmplx.Acos,
+	"atan":  cmplx.Atan,
+	"exp":   cmplx.Exp,
+	"log":   cmplx.Log,
+	"sqrt":  cmplx.Sqrt,
+	"conj":  cmplx.Conj,
+	"abs":   func(a complex128) complex128 { return complex(math.Abs(real(a)), math.Abs(imag(a))) },
+	"absre": func(a complex128) complex128 { return complex(math.Abs(real(a)), imag(a)) },
+	"absim": func(a complex128) complex128 { return complex(real(a), math.Abs(imag(a))) },
+	"re":    func(a complex128) complex128 { return complex(real(a), 0) },
+	"im":    func(a complex128) complex128 { return complex(imag(a), 0) },
+	"mod":   func(a complex128) complex128 { return complex(cmplx.Abs(a), 0) },
+	"arg":   func(a complex128) complex128 { return complex(cmplx.Phase(a), 0) },
+}
+
+// call returns the node of a function call.
+func call(t token, args []node) (node, error) {
+	if t.text == "pow" {
+		if len(args) != 2 {
+			return node{}, fmt.Errorf("pow expects 2 arguments, got %d at position %d", len(args), t.pos)
+		}
+		return pow(args[0], args[1]), nil
+	}
+	f, ok := functions[t.text]
+	if !ok {
+		return node{}, fmt.Errorf("unknown function %q at position %d", t.text, t.pos)
+	}
+	if len(args) != 1 {
+		return node{}, fmt.Errorf("%s expects 1 argument, got %d at position %d", t.text, len(args), t.pos)
+	}
+	return apply(args[0], f), nil
+}
+
+// apply returns the node of a function applied to n.
+func apply(n node, f func(complex128) complex128) node {
+	if n.isVal {
+		return constant(f(n.val))
+	}
+	g := n.f
+	return node{f: func(z, c, coef complex128) complex128 { return f(g(z, c, coef)) }}
+}
+
+// binary returns the node of the operator applied to n and m.
+func binary(n, m node, op func(a, b complex128) complex128) node {
+	if n.isVal && m.isVal {
+		return constant(op(n.val, m.val))
+	}
+	f, g := n.f, m.f
+	return node{f: func(z, c, coef complex128) complex128 { return op(f(z, c, coef), g(z, c, coef)) }}
+}
+
+// pow returns the node of n to the power of m. Constant integer exponents are
+// computed by repeated squaring.
+func pow(n, m node) node {
+	if !m.isVal || imag(m.val) != 0 || real(m.val) != math.Trunc(real(m.val)) || math.Abs(real(m.val)) > 1<<16 {
+		return binary(n, m, cmplx.Pow)
+	}
+	k := int(real(m.val))
+	return apply(n, func(a complex128) complex128 { return intPow(a, k) })
+}
+
+// intPow returns a to the power of the integer k.
+func intPow(a complex128, k int) complex128 {
+	if k < 0 {
+		return 1 / intPow(a, -k)
+	}
+	r := complex(1, 0)
+	for ; k > 0; k >>= 1 {
+		if k&1 == 1 {
+			r *= a
+		}
+		a *= a
+	}
+	return r
+}
@@ -0,0 +1,73 @@
+package expr
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		src  string
+		want func(z, c, coef complex128) complex128
+	}{
+		{"z^2 + c", func(z, c, _ complex128) complex128 { return z*z + c }},
+		{"coef*z*z + coef*c", func(z, c, coef complex128) complex128 { return coef*z*z + coef*c }},
+		{"z^3 + c*sin(z)", func(z, c, _ complex128) complex128 { return z*z*z + c*cmplx.Sin(z) }},
+		{"conj(z)^2 + coef*c", func(z, c, coef complex128) complex128 { return cmplx.Conj(z)*cmplx.Conj(z) + coef*c }},
+		{"abs(z)^2 + c", func(z, c, _ complex128) complex128 {
+			a := complex(math.Abs(real(z)), math.Abs(imag(z)))
+			return a*a + c
+		}},
+		{"absre(z^2) + c", func(z, c, _ complex128) complex128 {
+			a := z * z
+			return complex(math.Abs(real(a)), imag(a)) + c
+		}},
+		{"-z^-2 + 2i*c - 1.5e-1", func(z, c, _ complex128) complex128 { return -1/(z*z) + 2i*c - 0.15 }},
+		{"z^2.5 + pow(c, 1i)", func(z, c, _ complex128) complex128 { return cmplx.Pow(z, 2.5) + cmplx.Pow(c, 1i) }},
+		{"(z - c) / (exp(z) + log(c)) * mod(z) + re(c) - im(z)", func(z, c, _ complex128) complex128 {
+			return (z-c)/(cmplx.Exp(z)+cmplx.Log(c))*complex(cmplx.Abs(z), 0) + complex(real(c), 0) - complex(imag(z), 0)
+		}},
+		{"2^3^2 + 0*z", func(z, _, _ complex128) complex128 { return 512 }},
+	}
+	z, c, coef := complex(0.3, -0.7), complex(-0.5, 0.2), complex(1, 0.5)
+	for _, test := range tests {
+		f, err := Compile(test.src)
+		if err != nil {
+			t.Errorf("%q: %v", test.src, err)
+			continue
+		}
+		if got, want := f(z, c, coef), test.want(z, c, coef); cmplx.Abs(got-want) > 1e-12 {
+			t.Errorf("%q: expected %v, got %v", test.src, want, got)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, src := range []string{
+		"",
+		"z +",
+		"z^2 + x",
+		"foo(z)",
+		"sin(z, c)",
+		"pow(z)",
+		"(z + c",
+		"z c",
+		"z $ c",
+	} {
+		if _, err := Compile(src); err == nil {
+			t.Errorf("%q: expected error", src)
+		}
+	}
+}
+
+func BenchmarkCompiled(b *testing.B) {
+	f := MustCompile("z^2 + c")
+	z, c := complex(0.1, 0.2), complex(-0.5, 0.3)
+	for i := 0; i < b.N; i++ {
+		z = f(z, c, 1)
+		if real(z)*real(z)+imag(z)*imag(z) > 4 {
+			z = 0
+		}
+	}
+}
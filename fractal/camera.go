@@ -0,0 +1,73 @@
+package fractal
+
+import (
+	"image"
+	"math"
+	"math/cmplx"
+)
+
+// Camera maps the points of the projected plane to the pixels of the image and
+// back. The center of the camera is at the center of the image, the real axis
+// points down and the imaginary axis points right, which renders the
+// buddhabrot upright.
+type Camera struct {
+	Width, Height int        // Dimensions of the image in pixels.
+	Center        complex128 // Point of the plane at the center of the image.
+	Scale         float64    // Pixels per unit of the plane, vertically.
+	Rotation      float64    // Rotation in radians of the camera around its center.
+	Aspect        float64    // Width of the pixels relative to their height.
+}
+
+// NewCamera returns a camera of square pixels centered on the point. The zoom
+// level 1 fits the real range [-2, 2] around the center to the height of the
+// image.
+func NewCamera(width, height int, center complex128, zoom float64) Camera {
+	return Camera{
+		Width:  width,
+		Height: height,
+		Center: center,
+		Scale:  zoom * float64(height) / 4,
+		Aspect: 1,
+	}
+}
+
+// Zoom returns the zoom level of the camera, see NewCamera.
+func (cam Camera) Zoom() float64 {
+	return cam.Scale * 4 / float64(cam.Height)
+}
+
+// ToImage returns the image coordinates of the point of the plane. Pixel (x,
+// y) covers the coordinates [x, x+1) x [y, y+1).
+func (cam Camera) ToImage(p complex128) (x, y float64) {
+	d := p - cam.Center
+	if cam.Rotation != 0 {
+		d *= cmplx.Rect(1, -cam.Rotation)
+	}
+	x = float64(cam.Width)/2 + imag(d)*cam.Scale/cam.Aspect
+	y = float64(cam.Height)/2 + real(d)*cam.Scale
+	return x, y
+}
+
+// ToPlane returns the point of the plane at the image coordinates. It is the
+// inverse of ToImage.
+func (cam Camera) ToPlane(x, y float64) complex128 {
+	d := complex(
+		(y-float64(cam.Height)/2)/cam.Scale,
+		(x-float64(cam.Width)/2)*cam.Aspect/cam.Scale)
+	if cam.Rotation != 0 {
+		d *= cmplx.Rect(1, cam.Rotation)
+	}
+	return cam.Center + d
+}
+
+// Pixel returns the pixel of the point of the plane.
+func (cam Camera) Pixel(p complex128) image.Point {
+	x, y := cam.ToImage(p)
+	return image.Point{X: int(math.Floor(x)), Y: int(math.Floor(y))}
+}
+
+// PixelCenter returns the point of the plane at the center of the pixel. It is
+// the inverse of Pixel.
+func (cam Camera) PixelCenter(x, y int) complex128 {
+	return cam.ToPlane(float64(x)+0.5, float64(y)+0.5)
+}
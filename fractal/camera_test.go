@@ -0,0 +1,100 @@
+package fractal
+
+import (
+	"image"
+	"math"
+	"math/cmplx"
+	"testing"
+
+	rand7i "github.com/7i/rand"
+)
+
+func TestCameraRoundTrip(t *testing.T) {
+	cams := []Camera{
+		NewCamera(8, 8, 0, 1),
+		NewCamera(640, 480, complex(-0.75, 0.1), 3),
+		{Width: 300, Height: 200, Center: complex(0.3, -0.5), Scale: 1e6, Rotation: 0.7, Aspect: 1},
+		{Width: 17, Height: 31, Center: complex(-1, 1), Scale: 10, Rotation: -2, Aspect: 2.5},
+	}
+	rng := rand7i.NewComplexRNG(1)
+	for _, cam := range cams {
+		span := 4 / cam.Zoom()
+		for i := 0; i < 100; i++ {
+			p := cam.Center + rng.Complex128Go()*complex(span, 0)
+			x, y := cam.ToImage(p)
+			if got := cam.ToPlane(x, y); cmplx.Abs(got-p) > 1e-12*span {
+				t.Errorf("%+v: expected %v, got %v", cam, p, got)
+			}
+		}
+		for x := 0; x < cam.Width; x++ {
+			for y := 0; y < cam.Height; y++ {
+				want := image.Point{X: x, Y: y}
+				if got := cam.Pixel(cam.PixelCenter(x, y)); got != want {
+					t.Fatalf("%+v: expected %v, got %v", cam, want, got)
+				}
+			}
+		}
+	}
+}
+
+func TestCamera(t *testing.T) {
+	// Zoom 1 fits [-2, 2] to the height, the real axis points down and the
+	// imaginary axis right.
+	cam := NewCamera(200, 100, complex(1, 1), 1)
+	for _, test := range []struct {
+		p    complex128
+		x, y float64
+	}{
+		{complex(1, 1), 100, 50},
+		{complex(-1, 1), 100, 0},
+		{complex(3, 1), 100, 100},
+		{complex(1, 5), 200, 50},
+	} {
+		if x, y := cam.ToImage(test.p); math.Abs(x-test.x) > 1e-12 || math.Abs(y-test.y) > 1e-12 {
+			t.Errorf("%v: expected (%g, %g), got (%g, %g)", test.p, test.x, test.y, x, y)
+		}
+	}
+
+	// A quarter turn of the camera turns the imaginary axis down.
+	cam.Rotation = math.Pi / 2
+	if x, y := cam.ToImage(complex(1, 3)); math.Abs(x-100) > 1e-12 || math.Abs(y-100) > 1e-12 {
+		t.Errorf("rotated: expected (100, 100), got (%g, %g)", x, y)
+	}
+
+	// Points just outside the image aren't truncated into its first pixels.
+	if p := NewCamera(8, 8, 0, 1).Pixel(complex(-2.1, -2.1)); p.X != -1 || p.Y != -1 {
+		t.Errorf("expected pixel (-1, -1), got %v", p)
+	}
+}
+
+func TestImageToComplex(t *testing.T) {
+	frac, err := FromConfig(Config{
+		Width:      64,
+		Height:     48,
+		Iterations: 1,
+		Bailout:    4,
+		Func:       func(z, c, _ complex128) complex128 { return z*z + c },
+		Register:   func(z, c complex128, _ *Orbit, _ *Fractal) int64 { return 0 },
+		Plane:      Crci,
+		Zoom:       2,
+		Offset:     complex(0.5, -0.25),
+		Roll:       0.3,
+		Aspect:     1.5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for x := 0; x < frac.Width; x++ {
+		for y := 0; y < frac.Height; y++ {
+			c := frac.ImageToComplex(x, y)
+			if p, ok := frac.Point(0, c); !ok || p != (image.Point{X: x, Y: y}) {
+				t.Fatalf("expected pixel (%d, %d), got %v", x, y, p)
+			}
+		}
+	}
+	// The offset is the negated center of the image.
+	offset := complex(0.5, -0.25)
+	if got := frac.Camera.ToPlane(float64(frac.Width)/2, float64(frac.Height)/2); cmplx.Abs(got+offset) > 1e-12 {
+		t.Errorf("expected the center %v, got %v", -offset, got)
+	}
+}
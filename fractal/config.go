@@ -0,0 +1,239 @@
+package fractal
+
+import (
+	"errors"
+	"time"
+
+	rand7i "github.com/7i/rand"
+
+	"github.com/karlek/wasabi/coloring"
+	"github.com/karlek/wasabi/histo"
+	"github.com/karlek/wasabi/prec"
+)
+
+// Config contains the options for creating a fractal. The options Width,
+// Height, Iterations, Bailout, Func or Memory and Register are required, the other
+// options have defaults when left as zero values.
+type Config struct {
+	Width, Height int                // The width and height of the image to be constructed.
+	Method        *coloring.Coloring // Coloring method for the orbits.
+
+	PlotImportance bool // Create an image of the sampling points color graded by their importance.
+
+	// Function specific options.
+	Iterations int64                                                // Number of iterations before assuming convergence.
+	Bailout    float64                                              // (Squared) bailout radius.
+	Plane      func(complex128, complex128) complex128              // Function to chose the capital plane, defaults to Zrzi.
+	Func       func(complex128, complex128, complex128) complex128  // The complex function to explore!
+	Memory     func(z, prev, c, coef complex128) complex128         // Complex function which also depends on the previous point of the orbit, used instead of Func.
+	Register   func(complex128, complex128, *Orbit, *Fractal) int64 // Registering function for the orbits.
+	Coef       complex128                                           // Complex coefficient used in the complex function.
+	Epsilon    float64                                              // Tolerance of the cycle detection, zero demands exact equality.
+	Roots      []complex128                                         // Roots of root-finding formulas, which converged orbits are classified by.
+
+	// Precision specific options, for zooms beyond the precision of float64.
+	Precision prec.Tier                                  // Precision tier of the iterations of the escaped, converged and primitive registrers.
+	Prec      func(z, c, coef prec.Complex) prec.Complex // The complex function in the precision tier, used instead of Func.
+	Bits      uint                                       // Precision in bits of the big tier.
+	Origin    prec.Complex                               // Origin of the points in the precision tier, see ComplexToImage.
+
+	// Rendering specific options.
+	Zoom   float64    // Zoom level of our render, defaults to 1.
+	Offset complex128 // Offset of the points of the render, the negated center of the camera.
+	Roll   float64    // Rotation in radians of the camera around its center.
+	Aspect float64    // Width of the pixels relative to their height, defaults to 1.
+
+	// Anti-aliasing specific options.
+	Splat         *Splat // Kernel which distributes the points to the cells of the histograms, nil registers points in the cell they fall in.
+	Supersampling int    // Cells of the histograms per pixel along each axis, zero or one disables supersampling.
+
+	// Sampling specific options.
+	Tries     float64 // Number of orbit attempts we will sample, defaults to 1.
+	Seed      int64   // The random seed we sample random points from.
+	Threshold int64   // Threshold length of orbits.
+	Sampler   Sampler // Strategy for choosing the starting points of orbits.
+	Julia     bool    // The starting point c is constant and only z is sampled.
+	Grid      *Grid   // Escape-time pre-pass of the starting points, nil disables it.
+	Filter    *Filter // Filter of the registered orbits, nil registers every orbit.
+
+	// Budget specific options.
+	TimeBudget time.Duration // Stop sampling after this duration, zero disables the budget.
+	Noise      float64       // Stop sampling when the noise falls below, zero disables the budget.
+
+	// Checkpoint specific options.
+	Checkpoint         string        // Path of the checkpoint file, empty disables checkpoints.
+	CheckpointInterval time.Duration // Minimum duration between checkpoints.
+
+	// Coloring method specific options.
+	PathPoints  int64   // Number of intermediate points used for path interpolation.
+	BezierLevel int     // Bezier interpolation level: 1 is linear, 2 is quadratic etc.
+	Nebula      *Nebula // Per-channel iteration limits of the nebula coloring.
+
+	Z       func(complex128, Source) complex128 // Sampling method of z, defaults to origo.
+	C       func(complex128, Source) complex128 // Sampling method of c, defaults to sampling CDomain.
+	CDomain Domain                              // Domain of the sampled points c, defaults to the rectangle [-2, 2)^2.
+	ZSource func(*rand7i.ComplexRNG) Source     // Creates the source of the points z of a worker, defaults to NewRandom.
+	CSource func(*rand7i.ComplexRNG) Source     // Creates the source of the points c of a worker, defaults to NewRandom.
+
+	Rotation Rotation // Rotation of the points before they are projected onto the plane.
+}
+
+// Validate returns an error describing the first invalid option of the
+// configuration.
+func (conf Config) Validate() error {
+	switch {
+	case conf.Width <= 0 || conf.Height <= 0:
+		return errors.New("width and height must be positive")
+	case conf.Iterations <= 0:
+		return errors.New("iterations must be positive")
+	case conf.Bailout <= 0:
+		return errors.New("bailout must be positive")
+	case conf.Func == nil && conf.Memory == nil:
+		return errors.New("missing complex function")
+	case conf.Register == nil:
+		return errors.New("missing registrer")
+	case conf.Zoom < 0:
+		return errors.New("zoom must not be negative")
+	case conf.Aspect < 0:
+		return errors.New("aspect must not be negative")
+	case conf.Supersampling < 0:
+		return errors.New("supersampling must not be negative")
+	case conf.Tries < 0:
+		return errors.New("tries must not be negative")
+	case conf.Threshold < 0:
+		return errors.New("threshold must not be negative")
+	case conf.Epsilon < 0:
+		return errors.New("epsilon must not be negative")
+	case conf.Precision != prec.Float64 && conf.Prec == nil:
+		return errors.New("the complex function doesn't support the precision " + conf.Precision.String())
+	case conf.Origin != nil && conf.Origin.Tier() != conf.Precision:
+		return errors.New("the origin must be of the precision " + conf.Precision.String())
+	case conf.Julia && conf.Sampler != Uniform:
+		return errors.New("julia buddhabrots require the uniform sampler")
+	case conf.TimeBudget < 0 || conf.Noise < 0:
+		return errors.New("budgets must not be negative")
+	case conf.Method != nil && conf.Method.Mode() == coloring.Nebula && conf.Nebula == nil:
+		return errors.New("nebula coloring requires the iteration limits of the channels")
+	}
+	if conf.Nebula != nil {
+		if err := conf.Nebula.validate(conf.Iterations); err != nil {
+			return err
+		}
+	}
+	if conf.Splat != nil {
+		if err := conf.Splat.validate(); err != nil {
+			return err
+		}
+	}
+	if conf.Filter != nil {
+		return conf.Filter.validate()
+	}
+	return nil
+}
+
+// FromConfig validates the configuration and returns a new fractal, with
+// defaults for the omitted options.
+func FromConfig(conf Config) (*Fractal, error) {
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+	if conf.Zoom == 0 {
+		conf.Zoom = 1
+	}
+	if conf.Tries == 0 {
+		conf.Tries = 1
+	}
+	if conf.Plane == nil {
+		conf.Plane = Zrzi
+	}
+	if conf.Z == nil {
+		conf.Z = func(complex128, Source) complex128 { return 0 }
+	}
+	if conf.C == nil {
+		conf.C = RandomPoint
+		if conf.CDomain != nil {
+			conf.C = InDomain(conf.CDomain)
+		}
+	}
+	if conf.CDomain == nil {
+		conf.CDomain = square
+	}
+	if conf.ZSource == nil {
+		conf.ZSource = NewRandom
+	}
+	if conf.CSource == nil {
+		conf.CSource = NewRandom
+	}
+	if conf.Precision != prec.Float64 && conf.Origin == nil {
+		conf.Origin = prec.New(conf.Precision, 0, conf.Bits)
+	}
+	return conf.fractal(), nil
+}
+
+// fractal returns a fractal with the options of the configuration.
+func (conf Config) fractal() *Fractal {
+	frac := &Fractal{
+		Width:  conf.Width,
+		Height: conf.Height,
+		Method: conf.Method,
+
+		Importance:     histo.New(conf.Width, conf.Height),
+		PlotImportance: conf.PlotImportance,
+
+		Iterations: conf.Iterations,
+		Bailout:    conf.Bailout,
+		Plane:      conf.Plane,
+		Func:       conf.Func,
+		Memory:     conf.Memory,
+		Register:   conf.Register,
+		Coef:       conf.Coef,
+		Epsilon:    conf.Epsilon,
+		Roots:      conf.Roots,
+
+		Precision: conf.Precision,
+		Prec:      conf.Prec,
+		Bits:      conf.Bits,
+		Origin:    conf.Origin,
+
+		Camera:        conf.camera(),
+		Splat:         conf.Splat,
+		Supersampling: conf.Supersampling,
+
+		Tries:     conf.Tries,
+		Seed:      conf.Seed,
+		Threshold: conf.Threshold,
+		Sampler:   conf.Sampler,
+		Julia:     conf.Julia,
+		Grid:      conf.Grid,
+		Filter:    conf.Filter,
+
+		TimeBudget: conf.TimeBudget,
+		Noise:      conf.Noise,
+
+		Checkpoint:         conf.Checkpoint,
+		CheckpointInterval: conf.CheckpointInterval,
+
+		PathPoints:  conf.PathPoints,
+		BezierLevel: conf.BezierLevel,
+		Nebula:      conf.Nebula,
+
+		Z:       conf.Z,
+		C:       conf.C,
+		CDomain: conf.CDomain,
+		ZSource: conf.ZSource,
+		CSource: conf.CSource,
+	}
+	frac.Clear()
+	frac.Rotate(conf.Rotation)
+	return frac
+}
+
+// camera returns the camera of the configuration.
+func (conf Config) camera() Camera {
+	cam := NewCamera(conf.Width, conf.Height, -conf.Offset, conf.Zoom)
+	cam.Rotation = conf.Roll
+	if conf.Aspect != 0 {
+		cam.Aspect = conf.Aspect
+	}
+	return cam
+}
@@ -0,0 +1,71 @@
+package fractal
+
+import "testing"
+
+func TestFromConfig(t *testing.T) {
+	conf := Config{
+		Width:      16,
+		Height:     8,
+		Iterations: 10,
+		Bailout:    4,
+		Func:       func(z, c, _ complex128) complex128 { return z*z + c },
+		Register:   func(complex128, complex128, *Orbit, *Fractal) int64 { return -1 },
+	}
+	frac, err := FromConfig(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if frac.Camera.Zoom() != 1 || frac.Tries != 1 || frac.Plane == nil || frac.Z == nil || frac.C == nil || frac.CDomain == nil || frac.ZSource == nil || frac.CSource == nil {
+		t.Errorf("expected defaults for omitted options, got %+v", frac)
+	}
+	// The coefficient is taken as given, since zero is a valid coefficient.
+	if frac.Coef != 0 {
+		t.Errorf("expected the coefficient 0, got %v", frac.Coef)
+	}
+	if frac.R.Width != 16 || frac.R.Height != 8 {
+		t.Errorf("expected 16x8 histograms, got %dx%d", frac.R.Width, frac.R.Height)
+	}
+
+	invalid := map[string]func(*Config){
+		"zero width":        func(c *Config) { c.Width = 0 },
+		"zero iterations":   func(c *Config) { c.Iterations = 0 },
+		"negative bailout":  func(c *Config) { c.Bailout = -4 },
+		"missing function":  func(c *Config) { c.Func = nil },
+		"missing registrer": func(c *Config) { c.Register = nil },
+		"negative zoom":     func(c *Config) { c.Zoom = -1 },
+		"negative aspect":   func(c *Config) { c.Aspect = -1 },
+		"supersampling":     func(c *Config) { c.Supersampling = -1 },
+		"splat radius":      func(c *Config) { c.Splat = &Splat{Kernel: Tent} },
+		"splat kernel":      func(c *Config) { c.Splat = &Splat{Kernel: 42} },
+		"large splat":       func(c *Config) { c.Splat = &Splat{Kernel: Gaussian, Radius: 100} },
+		"nebula limit":      func(c *Config) { c.Nebula = &Nebula{Iterations: [3]int64{5, 10, 20}} },
+		"nebula threshold":  func(c *Config) { c.Nebula = &Nebula{Iterations: [3]int64{5, 5, 5}, Threshold: [3]int64{-1}} },
+	}
+	for name, f := range invalid {
+		c := conf
+		f(&c)
+		if _, err := FromConfig(c); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestNebulaChannels(t *testing.T) {
+	n := &Nebula{Iterations: [3]int64{10, 100, 1000}, Threshold: [3]int64{0, 5, 50}}
+	for _, test := range []struct {
+		length           int64
+		red, green, blue float64
+	}{
+		{1, 1, 0, 0},
+		{5, 1, 1, 0},
+		{10, 0, 1, 0},
+		{50, 0, 1, 1},
+		{100, 0, 0, 1},
+		{1000, 0, 0, 0},
+	} {
+		r, g, b := n.Channels(test.length)
+		if r != test.red || g != test.green || b != test.blue {
+			t.Errorf("length %d: expected channels (%g, %g, %g), got (%g, %g, %g)", test.length, test.red, test.green, test.blue, r, g, b)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package fractal
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+	"math/cmplx"
+)
+
+// Domain is a region of the complex plane which starting points are sampled
+// from, or which orbits are trapped by.
+type Domain interface {
+	// Map maps the point (u, v) of the unit square [0, 1)^2 onto the domain,
+	// such that uniformly distributed points remain uniformly distributed.
+	Map(u, v float64) complex128
+	// Contains returns true if the point z is inside the domain.
+	Contains(z complex128) bool
+	// Bounds returns the smallest rectangle which contains the domain.
+	Bounds() Rectangle
+}
+
+// InDomain returns a sampling method of starting points which maps the points
+// of the source onto the domain.
+func InDomain(d Domain) func(complex128, Source) complex128 {
+	return func(_ complex128, src Source) complex128 {
+		p := src.Point()
+		return d.Map((real(p)+2)/4, (imag(p)+2)/4)
+	}
+}
+
+// Rectangle is a rectangular domain between two corners.
+type Rectangle struct {
+	Min, Max complex128
+}
+
+// square is the rectangle [-2, 2)^2 of the random points.
+var square = Rectangle{Min: complex(-2, -2), Max: complex(2, 2)}
+
+// Map maps the unit square onto the rectangle.
+func (r Rectangle) Map(u, v float64) complex128 {
+	d := r.Max - r.Min
+	return r.Min + complex(u*real(d), v*imag(d))
+}
+
+// Contains returns true if z is inside the rectangle.
+func (r Rectangle) Contains(z complex128) bool {
+	return real(r.Min) <= real(z) && real(z) < real(r.Max) &&
+		imag(r.Min) <= imag(z) && imag(z) < imag(r.Max)
+}
+
+// Bounds returns the rectangle.
+func (r Rectangle) Bounds() Rectangle {
+	return r
+}
+
+// Disc is a circular domain. A disc with the radius of the square root of the
+// bailout contains every point which doesn't escape immediately.
+type Disc struct {
+	Center complex128
+	Radius float64
+}
+
+// Map maps the unit square onto the disc.
+func (d Disc) Map(u, v float64) complex128 {
+	return d.Center + cmplx.Rect(d.Radius*math.Sqrt(u), 2*math.Pi*v)
+}
+
+// Contains returns true if z is inside the disc.
+func (d Disc) Contains(z complex128) bool {
+	return cmplx.Abs(z-d.Center) < d.Radius
+}
+
+// Bounds returns the square around the disc.
+func (d Disc) Bounds() Rectangle {
+	r := complex(d.Radius, d.Radius)
+	return Rectangle{Min: d.Center - r, Max: d.Center + r}
+}
+
+// Annulus is the domain between two concentric circles. It is useful to
+// exclude the interior of a set; the annulus around -0.5 with the radii 0.25
+// and 1.5 contains the boundary of the mandelbrot set.
+type Annulus struct {
+	Center       complex128
+	Inner, Outer float64
+}
+
+// Map maps the unit square onto the annulus.
+func (a Annulus) Map(u, v float64) complex128 {
+	r := math.Sqrt(a.Inner*a.Inner + u*(a.Outer*a.Outer-a.Inner*a.Inner))
+	return a.Center + cmplx.Rect(r, 2*math.Pi*v)
+}
+
+// Contains returns true if z is between the circles of the annulus.
+func (a Annulus) Contains(z complex128) bool {
+	r := cmplx.Abs(z - a.Center)
+	return a.Inner <= r && r < a.Outer
+}
+
+// Bounds returns the square around the outer circle of the annulus.
+func (a Annulus) Bounds() Rectangle {
+	r := complex(a.Outer, a.Outer)
+	return Rectangle{Min: a.Center - r, Max: a.Center + r}
+}
+
+// Mask is a domain given by the white pixels of an image, stretched over a
+// rectangle. The rows of the image are mapped to increasing imaginary values.
+type Mask struct {
+	Rectangle
+	width, height int
+	pixels        []image.Point // The pixels inside the domain.
+	inside        []bool        // Whether the pixels, row by row, are inside the domain.
+}
+
+// NewMask returns the domain of the white pixels of img, stretched over the
+// rectangle between min and max.
+func NewMask(img image.Image, min, max complex128) (*Mask, error) {
+	bounds := img.Bounds()
+	m := &Mask{
+		Rectangle: Rectangle{Min: min, Max: max},
+		width:     bounds.Dx(),
+		height:    bounds.Dy(),
+		inside:    make([]bool, bounds.Dx()*bounds.Dy()),
+	}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y >= 0x80 {
+				m.pixels = append(m.pixels, image.Pt(x-bounds.Min.X, y-bounds.Min.Y))
+				m.inside[(y-bounds.Min.Y)*m.width+x-bounds.Min.X] = true
+			}
+		}
+	}
+	if len(m.pixels) == 0 {
+		return nil, errors.New("mask has no white pixels")
+	}
+	return m, nil
+}
+
+// Map chooses a pixel of the mask from u and maps the remainder of u and v
+// onto the pixel.
+func (m *Mask) Map(u, v float64) complex128 {
+	f := u * float64(len(m.pixels))
+	i := int(f)
+	// The unit square includes its upper edge, which maps to the last pixel.
+	if i >= len(m.pixels) {
+		i = len(m.pixels) - 1
+	}
+	p := m.pixels[i]
+	return m.Rectangle.Map(
+		(float64(p.X)+f-float64(i))/float64(m.width),
+		(float64(p.Y)+v)/float64(m.height))
+}
+
+// Contains returns true if z is inside a white pixel of the mask.
+func (m *Mask) Contains(z complex128) bool {
+	if !m.Rectangle.Contains(z) {
+		return false
+	}
+	d := m.Max - m.Min
+	x := int((real(z) - real(m.Min)) / real(d) * float64(m.width))
+	y := int((imag(z) - imag(m.Min)) / imag(d) * float64(m.height))
+	return x < m.width && y < m.height && m.inside[y*m.width+x]
+}
@@ -0,0 +1,69 @@
+package fractal
+
+import (
+	"image"
+	"image/color"
+	"math/cmplx"
+	"testing"
+
+	rand7i "github.com/7i/rand"
+)
+
+func TestDomain(t *testing.T) {
+	// Mask with the single white pixel (1, 0) of the rectangle [0, 2) x [0, 1).
+	img := image.NewGray(image.Rect(0, 0, 2, 1))
+	img.Set(1, 0, color.White)
+	mask, err := NewMask(img, 0, complex(2, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		d      Domain
+		inside func(complex128) bool
+	}{
+		{Rectangle{Min: complex(-1, 0), Max: complex(0, 2)}, func(p complex128) bool {
+			return real(p) >= -1 && real(p) < 0 && imag(p) >= 0 && imag(p) < 2
+		}},
+		{Disc{Center: 1, Radius: 2}, func(p complex128) bool { return cmplx.Abs(p-1) <= 2 }},
+		{Annulus{Center: -0.5, Inner: 0.25, Outer: 1.5}, func(p complex128) bool {
+			return cmplx.Abs(p+0.5) >= 0.25 && cmplx.Abs(p+0.5) <= 1.5
+		}},
+		{mask, func(p complex128) bool {
+			return real(p) >= 1 && real(p) < 2 && imag(p) >= 0 && imag(p) < 1
+		}},
+	}
+	rng := rand7i.NewComplexRNG(1)
+	src := NewRandom(&rng)
+	for _, test := range tests {
+		point := InDomain(test.d)
+		for i := 0; i < 1e4; i++ {
+			if p := point(0, src); !test.inside(p) || !test.d.Contains(p) {
+				t.Fatalf("%T: point %v outside of the domain", test.d, p)
+			}
+		}
+		// The sampled points of the unit square may be exactly 1.
+		for _, uv := range [][2]float64{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+			if p := test.d.Map(uv[0], uv[1]); cmplx.IsNaN(p) {
+				t.Fatalf("%T: expected a point of %v, got %v", test.d, uv, p)
+			}
+		}
+		// Points off the boundaries of the domains.
+		bounds := test.d.Bounds()
+		for x := -3.013; x < 3; x += 0.1 {
+			for y := -3.013; y < 3; y += 0.1 {
+				p := complex(x, y)
+				if test.d.Contains(p) != test.inside(p) {
+					t.Fatalf("%T: expected Contains(%v) to be %t", test.d, p, test.inside(p))
+				}
+				if test.d.Contains(p) && !bounds.Contains(p) {
+					t.Fatalf("%T: point %v outside the bounds %v", test.d, p, bounds)
+				}
+			}
+		}
+	}
+
+	if _, err := NewMask(image.NewGray(image.Rect(0, 0, 2, 2)), 0, 1); err == nil {
+		t.Errorf("expected error for a mask without white pixels")
+	}
+}
@@ -0,0 +1,69 @@
+package fractal
+
+import (
+	"errors"
+	"math/cmplx"
+)
+
+// Filter selects the registered orbits by their properties, which isolates
+// layers of a render such as the orbits of period 3 components. The zero value
+// of an option disables it.
+type Filter struct {
+	MinLength, MaxLength int64   // Window of orbit lengths.
+	MinAngle, MaxAngle   float64 // Range in (-π, π] of the argument of the last point, which wraps around if the minimum is larger than the maximum.
+	MinRadius, MaxRadius float64 // Band of the modulus of the last point.
+	Period               int64   // Period of the cycle the orbit converged to.
+	Trap                 Domain  // Shape which the orbit must enter.
+}
+
+// validate returns an error describing the first invalid option of the filter.
+func (f *Filter) validate() error {
+	switch {
+	case f.MinLength < 0 || f.MaxLength < 0:
+		return errors.New("filter lengths must not be negative")
+	case f.MaxLength != 0 && f.MinLength > f.MaxLength:
+		return errors.New("filter minimum length must not exceed the maximum length")
+	case f.MinRadius < 0 || f.MaxRadius < 0:
+		return errors.New("filter radii must not be negative")
+	case f.MaxRadius != 0 && f.MinRadius > f.MaxRadius:
+		return errors.New("filter minimum radius must not exceed the maximum radius")
+	case f.Period < 0:
+		return errors.New("filter period must not be negative")
+	}
+	return nil
+}
+
+// Accept returns true if the orbit of the given length passes the filter.
+func (f *Filter) Accept(length int64, orbit *Orbit) bool {
+	if length < f.MinLength || (f.MaxLength != 0 && length > f.MaxLength) {
+		return false
+	}
+	if f.Period != 0 && orbit.Period != f.Period {
+		return false
+	}
+	if f.MinAngle != f.MaxAngle {
+		a := cmplx.Phase(orbit.Last)
+		if f.MinAngle < f.MaxAngle && (a < f.MinAngle || a > f.MaxAngle) {
+			return false
+		}
+		// The range wraps around the negative real axis.
+		if f.MinAngle > f.MaxAngle && a < f.MinAngle && a > f.MaxAngle {
+			return false
+		}
+	}
+	if f.MinRadius != 0 || f.MaxRadius != 0 {
+		r := cmplx.Abs(orbit.Last)
+		if r < f.MinRadius || (f.MaxRadius != 0 && r > f.MaxRadius) {
+			return false
+		}
+	}
+	if f.Trap != nil {
+		for _, z := range orbit.Points[:length] {
+			if f.Trap.Contains(z) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,35 @@
+package fractal
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	orbit := &Orbit{Points: []complex128{0, 0.5, 1i, 3}, Last: -3, Period: 3}
+	tests := []struct {
+		name   string
+		filter Filter
+		accept bool
+	}{
+		{"zero", Filter{}, true},
+		{"length window", Filter{MinLength: 2, MaxLength: 4}, true},
+		{"too short", Filter{MinLength: 5}, false},
+		{"too long", Filter{MaxLength: 2}, false},
+		{"period", Filter{Period: 3}, true},
+		{"other period", Filter{Period: 2}, false},
+		{"angle", Filter{MinAngle: 3, MaxAngle: math.Pi}, true},
+		{"other angle", Filter{MinAngle: -1, MaxAngle: 1}, false},
+		{"wrapping angle", Filter{MinAngle: 3, MaxAngle: -3}, true},
+		{"radius band", Filter{MinRadius: 2, MaxRadius: 4}, true},
+		{"outside band", Filter{MinRadius: 4}, false},
+		{"trap", Filter{Trap: Disc{Center: 1i, Radius: 0.1}}, true},
+		{"missed trap", Filter{Trap: Disc{Center: -1i, Radius: 0.1}}, false},
+		{"trap after length", Filter{Trap: Disc{Center: 3, Radius: 0.1}}, false},
+	}
+	for _, test := range tests {
+		if got := test.filter.Accept(3, orbit); got != test.accept {
+			t.Errorf("%s: expected %t, got %t", test.name, test.accept, got)
+		}
+	}
+}
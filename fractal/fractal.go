@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"image"
 	"text/tabwriter"
+	"time"
 
 	rand7i "github.com/7i/rand"
 
 	"github.com/karlek/wasabi/coloring"
 	"github.com/karlek/wasabi/histo"
+	"github.com/karlek/wasabi/prec"
 	"github.com/karlek/wasabi/util"
 )
 
@@ -27,35 +29,59 @@ type Fractal struct {
 	Bailout    float64                                              // (Squared) bailout radius.
 	Plane      func(complex128, complex128) complex128              // Function to chose the capital plane.
 	Func       func(complex128, complex128, complex128) complex128  // The complex function to explore!
+	Memory     func(z, prev, c, coef complex128) complex128         // Complex function which also depends on the previous point of the orbit, used instead of Func.
 	Register   func(complex128, complex128, *Orbit, *Fractal) int64 // Registering function for the orbits.
 	Coef       complex128                                           // Complex coefficient used in the complex function.
+	Epsilon    float64                                              // Tolerance of the cycle detection, zero demands exact equality.
+	Roots      []complex128                                         // Roots of root-finding formulas, which converged orbits are classified by.
+
+	// Precision specific options, for zooms beyond the precision of float64.
+	Precision prec.Tier                                  // Precision tier of the iterations of the escaped, converged and primitive registrers.
+	Prec      func(z, c, coef prec.Complex) prec.Complex // The complex function in the precision tier, used instead of Func.
+	Bits      uint                                       // Precision in bits of the big tier.
+	Origin    prec.Complex                               // Origin of the points in precision tiers beyond float64, see ComplexToImage.
 
 	// Rendering specific options.
-	Zoom   float64    // Zoom level of our render.
-	Offset complex128 // Offset the camera center for the render.
+	Camera        Camera // Maps the projected points to the pixels of the image.
+	Splat         *Splat // Kernel which distributes the points to the cells of the histograms, nil registers points in the cell they fall in.
+	Supersampling int    // Cells of the histograms per pixel along each axis, which are downsampled when plotted.
 
 	// Sampling specific options.
 	Tries     float64 // Number of orbit attempts we will sample.
 	Seed      int64   // The random seed we sample random points from.
 	Threshold int64   // Threshold length of orbits.
+	Sampler   Sampler // Strategy for choosing the starting points of orbits.
+	Julia     bool    // The starting point c is constant and only z is sampled.
+	Grid      *Grid   // Escape-time pre-pass of the starting points, nil disables it.
+	Filter    *Filter // Filter of the registered orbits, nil registers every orbit.
 
-	// Coloring method specific options.
-	PathPoints  int64 // Number of intermediate points used for path interpolation.
-	BezierLevel int   // Bezier interpolation level: 1 is linear, 2 is quadratic etc.
-
-	Z, C func(complex128, *rand7i.ComplexRNG) complex128
+	// Budget specific options.
+	TimeBudget time.Duration // Stop sampling after this duration, zero disables the budget.
+	Noise      float64       // Stop sampling when the noise falls below, zero disables the budget.
 
-	// Calculation specific.
-	ratio float64
-	xZoom float64
-	yZoom float64
+	// Checkpoint specific options.
+	Checkpoint         string        // Path of the checkpoint file, empty disables checkpoints.
+	CheckpointInterval time.Duration // Minimum duration between checkpoints.
 
-	// Experimental options.
-	Theta  float64 // Matrix rotation angle.
-	Theta2 float64 // Second matrix rotation angle.
+	// Coloring method specific options.
+	PathPoints  int64   // Number of intermediate points used for path interpolation.
+	BezierLevel int     // Bezier interpolation level: 1 is linear, 2 is quadratic etc.
+	Nebula      *Nebula // Per-channel iteration limits of the nebula coloring.
+
+	Z, C    func(complex128, Source) complex128 // Sampling methods of the starting points.
+	CDomain Domain                              // Domain of the sampled points c, which mutated and adaptively sampled points are kept inside.
+	ZSource func(*rand7i.ComplexRNG) Source     // Creates the source of the points z of a worker.
+	CSource func(*rand7i.ComplexRNG) Source     // Creates the source of the points c of a worker.
+
+	// Rotation of the points before projection, set by Rotate.
+	rotation Rotation
+	matrix   matrix
+	rotated  bool
 }
 
 // New returns a new render for fractals.
+//
+// Deprecated: Use FromConfig, which validates the options.
 func New(width, height int,
 	iterations int64,
 	method *coloring.Coloring,
@@ -72,44 +98,33 @@ func New(width, height int,
 	tries float64,
 	register func(complex128, complex128, *Orbit, *Fractal) int64,
 	theta float64,
-	z, c func(complex128, *rand7i.ComplexRNG) complex128,
+	z, c func(complex128, Source) complex128,
 	threshold int64) *Fractal {
-	r, g, b := histo.New(width, height), histo.New(width, height), histo.New(width, height)
-	importance := histo.New(width, height)
-
-	ratio := float64(width) / float64(height)
-	return &Fractal{
-		Width:  width,
-		Height: height,
-
-		ratio: ratio,
-		xZoom: zoom * float64(width/4) * (1 / ratio),
-		yZoom: zoom * float64(height/4),
-
-		Z: z,
-		C: c,
-
-		Importance:     importance,
+	return Config{
+		Width:          width,
+		Height:         height,
+		Iterations:     iterations,
+		Method:         method,
+		Coef:           coef,
+		Bailout:        bailout,
+		Plane:          plane,
+		Func:           f,
+		Zoom:           zoom,
+		Offset:         offset,
 		PlotImportance: plotImportance,
-
-		Iterations:  iterations,
-		R:           r,
-		G:           g,
-		B:           b,
-		Method:      method,
-		Coef:        coef,
-		Bailout:     bailout,
-		Plane:       plane,
-		Zoom:        zoom,
-		Offset:      offset,
-		Seed:        seed,
-		PathPoints:  points,
-		BezierLevel: bezierLevel,
-		Tries:       tries,
-		Register:    register,
-		Func:        f,
-		Theta:       theta,
-		Threshold:   threshold}
+		Seed:           seed,
+		PathPoints:     points,
+		BezierLevel:    bezierLevel,
+		Tries:          tries,
+		Register:       register,
+		Rotation:       Rotation{ZrCr: theta},
+		Z:              z,
+		C:              c,
+		CDomain:        square,
+		ZSource:        NewRandom,
+		CSource:        NewRandom,
+		Threshold:      threshold,
+	}.fractal()
 }
 
 func Zrzi(z complex128, c complex128) complex128 { return complex(real(z), imag(z)) }
@@ -118,6 +133,7 @@ func Zrci(z complex128, c complex128) complex128 { return complex(real(z), imag(
 func Zicr(z complex128, c complex128) complex128 { return complex(imag(z), real(c)) }
 func Zici(z complex128, c complex128) complex128 { return complex(imag(z), imag(c)) }
 func Crci(z complex128, c complex128) complex128 { return complex(real(c), imag(c)) }
+func Crzi(z complex128, c complex128) complex128 { return complex(real(c), imag(z)) }
 
 func (frac *Fractal) String() string {
 	var buf bytes.Buffer // A Buffer needs no initialization.
@@ -128,48 +144,63 @@ func (frac *Fractal) String() string {
 	fmt.Fprintf(w, "Plane:\t%v\n", util.FunctionName(frac.Plane))
 	fmt.Fprintf(w, "Coef:\t%v\n", frac.Coef)
 	fmt.Fprintf(w, "Bail:\t%f\n", frac.Bailout)
-	fmt.Fprintf(w, "Zoom:\t%f\n", frac.Zoom)
-	fmt.Fprintf(w, "Offset:\t%v\n", frac.Offset)
+	fmt.Fprintf(w, "Zoom:\t%f\n", frac.Camera.Zoom())
+	fmt.Fprintf(w, "Center:\t%v\n", frac.Camera.Center)
+	fmt.Fprintf(w, "Roll:\t%f\n", frac.Camera.Rotation)
+	fmt.Fprintf(w, "Supersampling:\t%d\n", frac.Supersampling)
 	fmt.Fprintf(w, "Seed:\t%d\n", frac.Seed)
 	fmt.Fprintf(w, "Points:\t%d\n", frac.PathPoints)
 	fmt.Fprintf(w, "Tries:\t%.f\n", frac.Tries)
+	fmt.Fprintf(w, "Sampler:\t%v\n", frac.Sampler)
+	fmt.Fprintf(w, "Rotation:\t%+v\n", frac.rotation)
+	fmt.Fprintf(w, "Precision:\t%v\n", frac.Precision)
 	w.Flush()
 	return string(buf.Bytes())
 }
 
 // Clear removes old histogram data. Useful for interactive rendering.
 func (frac *Fractal) Clear() {
-	frac.R = histo.New(frac.Width, frac.Height)
-	frac.G = histo.New(frac.Width, frac.Height)
-	frac.B = histo.New(frac.Width, frac.Height)
+	w, h := frac.cells()
+	frac.R = histo.New(w, h)
+	frac.G = histo.New(w, h)
+	frac.B = histo.New(w, h)
 }
 
-// X translates the real value of the complex point to an X-coordinate in the
-// image plane.
-func (frac Fractal) X(r float64) int {
-	return int(frac.xZoom*(r+real(frac.Offset)) + float64(frac.Width)/2.0)
-}
-
-// Y translates the imaginary value of the complex point to an Y-coordinate in
-// the image plane.
-func (frac Fractal) Y(i float64) int {
-	return int(frac.yZoom*(i+imag(frac.Offset)) + float64(frac.Height)/2.0)
+// cells returns the dimensions of the histograms, which are the dimensions of
+// the image times the supersampling.
+func (frac *Fractal) cells() (width, height int) {
+	if frac.Supersampling <= 1 {
+		return frac.Width, frac.Height
+	}
+	return frac.Width * frac.Supersampling, frac.Height * frac.Supersampling
 }
 
-// RandomPoint initializes each iteration with a random point.
-func RandomPoint(_ complex128, rng *rand7i.ComplexRNG) complex128 {
-	return rng.Complex128Go()
+// RandomPoint initializes each iteration with the next point of the source.
+func RandomPoint(_ complex128, src Source) complex128 {
+	return src.Point()
 }
 
+// Importance returns the fractal of the importance map, whose image is the
+// bounds of the domain of the sampled points c.
 func Importance(frac *Fractal) *Fractal {
+	bounds := square
+	if frac.CDomain != nil {
+		bounds = frac.CDomain.Bounds()
+	}
+	// The real axis spans the height and the imaginary axis the width.
+	size := bounds.Max - bounds.Min
+	scale := float64(frac.Height) / real(size)
 	f := Fractal{
 		Width:  frac.Width,
 		Height: frac.Height,
-		Offset: complex(0, 0),
 		Plane:  Crci,
-		ratio:  frac.ratio,
-		xZoom:  1 * float64(frac.Width/4) * (1 / frac.ratio),
-		yZoom:  1 * float64(frac.Height/4),
+		Camera: Camera{
+			Width:  frac.Width,
+			Height: frac.Height,
+			Center: (bounds.Min + bounds.Max) / 2,
+			Scale:  scale,
+			Aspect: scale * imag(size) / float64(frac.Width),
+		},
 	}
 	return &f
 }
@@ -185,56 +216,68 @@ func (frac *Fractal) Point(z, c complex128) (image.Point, bool) {
 	return p, true
 }
 
-func initializeRot() {
-	// var rotX *mat64.Dense
-	// var rotSomething *mat64.Dense
-
-	// rotX = mat64.NewDense(4, 4, []float64{
-	// 	math.Cos(frac.Theta2), math.Sin(frac.Theta2), 0, 0,
-	// 	-math.Sin(frac.Theta2), math.Cos(frac.Theta2), 0, 0,
-	// 	0, 0, 1, 0,
-	// 	0, 0, 0, 1,
-	// })
-
-	// rotSomething = mat64.NewDense(4, 4, []float64{
-	// 	1, 0, 0, 0,
-	// 	0, 1, 0, 0,
-	// 	0, 0, math.Cos(frac.Theta), -math.Sin(frac.Theta),
-	// 	0, 0, math.Sin(frac.Theta), math.Cos(frac.Theta),
-	// })
-	// rotSomething = mat64.NewDense(4, 4, []float64{
-	// 	math.Cos(frac.Theta), 0, -math.Sin(frac.Theta), 0,
-	// 	0, 1, 0, 0,
-	// 	math.Sin(frac.Theta), 0, math.Cos(frac.Theta), 0,
-	// 	0, 0, 0, 1,
-	// })
+// ComplexToImage rotates the point (z, c), projects it onto the plane and
+// converts it to a pixel coordinate.
+//
+// In precision tiers beyond float64 the points z of the orbits are relative to
+// the origin (z, c) = (origin, origin), which the registrers subtract in full
+// precision, so the center of the camera is relative to the projected origin.
+// The sampled points c are absolute.
+func (frac *Fractal) ComplexToImage(z, c complex128) image.Point {
+	return frac.Camera.Pixel(frac.projectOrigin(z, c))
 }
 
-// ptoc converts a point from the complex function to a pixel coordinate.
-//
-// Stands for point to coordinate, which is actually a really shitty name
-// because of it's ambiguous character haha.
-func (frac *Fractal) ComplexToImage(z, c complex128) (p image.Point) {
-	// r, i := real(z), imag(z)
+// projectOrigin projects the point (z, c) onto the plane, relative to the
+// origin of the precision tier, see ComplexToImage.
+func (frac *Fractal) projectOrigin(z, c complex128) complex128 {
+	if frac.Precision != prec.Float64 {
+		c -= frac.Origin.Complex128()
+	}
+	return frac.Project(z, c)
+}
 
-	// var rotVec mat64.Vector
-	// x := mat64.NewVector(4, []float64{real(z), imag(z), real(c), imag(c)})
-	// rotVec = *x
-	// rotVec.MulVec(rotX, x)
-	// rotVec.MulVec(rotSomething, &rotVec)
+// Cell returns the continuous coordinates of the point (z, c) in the cells of
+// the histograms, which are the image coordinates times the supersampling.
+func (frac *Fractal) Cell(z, c complex128) (x, y float64) {
+	x, y = frac.Camera.ToImage(frac.projectOrigin(z, c))
+	if s := frac.Supersampling; s > 1 {
+		x, y = x*float64(s), y*float64(s)
+	}
+	return x, y
+}
 
-	// tmp := frac.Plane(complex(rotVec.At(0, 0), rotVec.At(1, 0)),
-	// complex(rotVec.At(2, 0), rotVec.At(3, 0)))
-	tmp := frac.Plane(z, c)
+// CellPoint returns the cell of the histograms which the point (z, c) falls in,
+// and false if it is outside the histograms.
+func (frac *Fractal) CellPoint(z, c complex128) (image.Point, bool) {
+	x, y := frac.Cell(z, c)
+	w, h := frac.cells()
+	// Negated to reject NaN.
+	if !(x >= 0 && y >= 0 && x < float64(w) && y < float64(h)) {
+		return image.Point{}, false
+	}
+	return image.Point{X: int(x), Y: int(y)}, true
+}
 
-	p.X = frac.X(real(tmp))
-	p.Y = frac.Y(imag(tmp))
+// ImageToPrec returns the point of the pixel in the precision tier, relative to
+// the origin.
+func (frac *Fractal) ImageToPrec(x, y int) prec.Complex {
+	d := prec.New(frac.Precision, frac.ImageToComplex(x, y), frac.Bits)
+	if frac.Origin == nil {
+		return d
+	}
+	return frac.Origin.Add(d)
+}
 
-	return p
+// Project rotates the point (z, c) and projects it onto the plane.
+func (frac *Fractal) Project(z, c complex128) complex128 {
+	if frac.rotated {
+		z, c = frac.matrix.apply(z, c)
+	}
+	return frac.Plane(z, c)
 }
 
+// ImageToComplex returns the point of the plane at the center of the pixel. It
+// is the inverse of ComplexToImage for the crci plane without rotation.
 func (frac *Fractal) ImageToComplex(x, y int) complex128 {
-	r := 2 / frac.Zoom * (2*(float64(x)/float64(frac.Width)+real(frac.Offset)) - 1)
-	i := 2 / frac.Zoom * (2*(float64(y)/float64(frac.Height)+imag(frac.Offset)) - 1)
-	return complex(r, i)
+	return frac.Camera.PixelCenter(x, y)
 }
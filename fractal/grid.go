@@ -0,0 +1,144 @@
+package fractal
+
+import (
+	"runtime"
+	"sync"
+)
+
+// Cell is the classification of the starting points c inside a cell of the
+// pre-pass grid.
+type Cell uint8
+
+// Classifications of the cells.
+const (
+	Unknown  Cell = iota // The orbits must be iterated.
+	Interior             // The orbits never escape.
+	Exterior             // The orbits escape at the first iteration.
+)
+
+// Grid is a coarse escape-time pre-pass over the starting points c in the
+// square [-2, 2) x [-2, 2), which lets registrers skip starting points that are
+// likely interior or escape trivially. The classification is an approximation
+// from 3x3 samples per cell, eroded by one cell, which may misclassify features
+// finer than the cells. Points outside the square are unknown.
+type Grid struct {
+	size  int
+	cells []Cell
+}
+
+// Number of sample points along each side of a cell.
+const gridSamples = 3
+
+// NewGrid computes the pre-pass grid of the fractal with size x size cells.
+// The starting point z is given by c; sampled z is assumed to be zero.
+//
+// A cell is classified only if all sample points of the cell and its eight
+// neighbours agree, which keeps thin filaments of the boundary from being
+// skipped.
+func NewGrid(frac *Fractal, size int) *Grid {
+	raw := make([]Cell, size*size)
+	var wg sync.WaitGroup
+	rows := make(chan int)
+	for n := 0; n < runtime.NumCPU(); n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for y := range rows {
+				for x := 0; x < size; x++ {
+					raw[y*size+x] = classify(frac, x, y, size)
+				}
+			}
+		}()
+	}
+	for y := 0; y < size; y++ {
+		rows <- y
+	}
+	close(rows)
+	wg.Wait()
+
+	// Erode the classified regions by one cell.
+	g := &Grid{size: size, cells: make([]Cell, size*size)}
+	for y := 1; y < size-1; y++ {
+	cells:
+		for x := 1; x < size-1; x++ {
+			cell := raw[y*size+x]
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					if raw[(y+dy)*size+x+dx] != cell {
+						continue cells
+					}
+				}
+			}
+			g.cells[y*size+x] = cell
+		}
+	}
+	return g
+}
+
+// classify classifies the sample points of the cell (x, y).
+func classify(frac *Fractal, x, y, size int) Cell {
+	w := 4 / float64(size)
+	cell := Unknown
+	for i := 0; i < gridSamples; i++ {
+		for j := 0; j < gridSamples; j++ {
+			c := complex(
+				-2+w*(float64(x)+float64(i)/(gridSamples-1)),
+				-2+w*(float64(y)+float64(j)/(gridSamples-1)))
+			var got Cell
+			switch escape(frac, frac.Z(c, origin{}), c) {
+			case -1:
+				got = Interior
+			case 0:
+				got = Exterior
+			default:
+				return Unknown
+			}
+			if cell != Unknown && cell != got {
+				return Unknown
+			}
+			cell = got
+		}
+	}
+	return cell
+}
+
+// escape returns the iteration at which the orbit of z and c escapes the
+// bailout, or -1 if it doesn't escape.
+func escape(frac *Fractal, z, c complex128) int64 {
+	it := frac.Iterate(z)
+	// Saved values for cycle-detection.
+	var saved, savedPrev complex128
+	var i int64
+	for i = 0; i < frac.Iterations; i++ {
+		z = it.Next(z, c)
+		if (i-1)&i == 0 && i > 1 {
+			saved, savedPrev = z, it.Prev()
+		} else if z == saved && it.Prev() == savedPrev {
+			return -1
+		}
+		if x, y := real(z), imag(z); x*x+y*y >= frac.Bailout {
+			return i
+		}
+	}
+	return -1
+}
+
+// origin is a source which only returns zero.
+type origin struct{}
+
+// Point returns zero.
+func (origin) Point() complex128 { return 0 }
+
+// Cell returns the classification of the starting point c. A nil grid
+// classifies every point as unknown.
+func (g *Grid) Cell(c complex128) Cell {
+	if g == nil {
+		return Unknown
+	}
+	x := int((real(c) + 2) / 4 * float64(g.size))
+	y := int((imag(c) + 2) / 4 * float64(g.size))
+	if real(c) < -2 || imag(c) < -2 || x >= g.size || y >= g.size {
+		return Unknown
+	}
+	return g.cells[y*g.size+x]
+}
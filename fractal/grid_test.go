@@ -0,0 +1,47 @@
+package fractal
+
+import (
+	"testing"
+
+	rand7i "github.com/7i/rand"
+)
+
+func TestGrid(t *testing.T) {
+	frac := &Fractal{
+		Iterations: 1000,
+		Bailout:    4,
+		Coef:       1,
+		Func:       func(z, c, _ complex128) complex128 { return z*z + c },
+		Z:          func(complex128, Source) complex128 { return 0 },
+	}
+	g := NewGrid(frac, 32)
+	for c, want := range map[complex128]Cell{
+		complex(0.01, 0.01): Interior,
+		complex(1.7, 1.7):   Exterior,
+		complex(-0.75, 0):   Unknown,
+		complex(3, 0):       Unknown,
+	} {
+		if got := g.Cell(c); got != want {
+			t.Errorf("cell of %v: expected %d, got %d", c, want, got)
+		}
+	}
+
+	// The classified cells must agree with the orbits of their points.
+	rng := rand7i.NewComplexRNG(1)
+	for i := 0; i < 1e4; i++ {
+		c := rng.Complex128Go()
+		switch i := escape(frac, 0, c); g.Cell(c) {
+		case Interior:
+			if i != -1 {
+				t.Errorf("interior point %v escapes at %d", c, i)
+			}
+		case Exterior:
+			if i != 0 {
+				t.Errorf("exterior point %v escapes at %d", c, i)
+			}
+		}
+	}
+	if (*Grid)(nil).Cell(0) != Unknown {
+		t.Errorf("nil grid must classify points as unknown")
+	}
+}
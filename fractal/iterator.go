@@ -0,0 +1,33 @@
+package fractal
+
+// Iterator iterates the complex function of a fractal and carries the state of
+// an orbit, which formulas with memory depend on.
+type Iterator struct {
+	frac *Fractal
+	prev complex128 // Previous point of the orbit, for formulas with memory.
+}
+
+// Iterate returns an iterator of the orbit starting at z. Formulas with memory
+// start with the previous point equal to z.
+func (frac *Fractal) Iterate(z complex128) Iterator {
+	it := Iterator{frac: frac}
+	if frac.Memory != nil {
+		it.prev = z
+	}
+	return it
+}
+
+// Next returns the point of the orbit following z.
+func (it *Iterator) Next(z, c complex128) complex128 {
+	if it.frac.Memory == nil {
+		return it.frac.Func(z, c, it.frac.Coef)
+	}
+	z, it.prev = it.frac.Memory(z, it.prev, c, it.frac.Coef), z
+	return z
+}
+
+// Prev returns the point of the orbit preceding the last point returned by
+// Next. It is always zero for formulas without memory.
+func (it *Iterator) Prev() complex128 {
+	return it.prev
+}
@@ -0,0 +1,41 @@
+package fractal
+
+import "errors"
+
+// Nebula contains the iteration limits and thresholds of the red, green and
+// blue channels of a nebulabrot, in that order. The orbits are iterated once
+// under the iterations of the fractal, and each channel registers the orbits
+// whose length is below its limit and at least its threshold.
+type Nebula struct {
+	Iterations [3]int64 // Iteration limits of the channels.
+	Threshold  [3]int64 // Threshold lengths of the channels.
+}
+
+// validate returns an error if a limit of the channels exceeds the iterations
+// of the fractal.
+func (n *Nebula) validate(iterations int64) error {
+	for i := range n.Iterations {
+		switch {
+		case n.Iterations[i] <= 0:
+			return errors.New("nebula iteration limits must be positive")
+		case n.Iterations[i] > iterations:
+			return errors.New("nebula iteration limits must not exceed the iterations")
+		case n.Threshold[i] < 0:
+			return errors.New("nebula thresholds must not be negative")
+		}
+	}
+	return nil
+}
+
+// Channels returns the weights of the red, green and blue channels of an orbit
+// of the given length, which are one for the channels registering it and zero
+// otherwise.
+func (n *Nebula) Channels(length int64) (red, green, blue float64) {
+	var rgb [3]float64
+	for i := range rgb {
+		if n.Threshold[i] <= length && length < n.Iterations[i] {
+			rgb[i] = 1
+		}
+	}
+	return rgb[0], rgb[1], rgb[2]
+}
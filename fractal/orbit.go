@@ -4,4 +4,14 @@ package fractal
 type Orbit struct {
 	Points []complex128
 	C      complex128
+	Weight float64    // Weight multiplied to the color values of each registered point.
+	Period int64      // Period of the cycle the orbit converged to, zero if no cycle was detected.
+	Root   int        // Index of the root the orbit of a root-finding formula converged to, or the number of roots for attracting cycles.
+	Last   complex128 // The last point of the orbit, which is outside the bailout for escaping orbits.
+}
+
+// NewOrbit returns an orbit with room for the points of the given number of
+// iterations.
+func NewOrbit(iterations int64) *Orbit {
+	return &Orbit{Points: make([]complex128, iterations), Weight: 1}
 }
@@ -0,0 +1,35 @@
+package fractal
+
+import "strings"
+
+// Projection is a linear projection of the four dimensional points
+// (Zr, Zi, Cr, Ci) onto the image plane. The first row gives the horizontal
+// axis and the second row the vertical axis.
+type Projection [2][4]float64
+
+// Plane returns the plane function of the projection.
+func (p Projection) Plane() func(complex128, complex128) complex128 {
+	return func(z, c complex128) complex128 {
+		zr, zi, cr, ci := real(z), imag(z), real(c), imag(c)
+		return complex(
+			p[0][0]*zr+p[0][1]*zi+p[0][2]*cr+p[0][3]*ci,
+			p[1][0]*zr+p[1][1]*zi+p[1][2]*cr+p[1][3]*ci)
+	}
+}
+
+// planes are the capital planes by name.
+var planes = map[string]func(complex128, complex128) complex128{
+	"zrzi": Zrzi,
+	"zrcr": Zrcr,
+	"zrci": Zrci,
+	"zicr": Zicr,
+	"zici": Zici,
+	"crci": Crci,
+	"crzi": Crzi,
+}
+
+// NamedPlane returns the capital plane of the given name, e.g. zrzi or crci.
+func NamedPlane(name string) (plane func(complex128, complex128) complex128, ok bool) {
+	plane, ok = planes[strings.ToLower(name)]
+	return plane, ok
+}
@@ -0,0 +1,50 @@
+package fractal
+
+import "testing"
+
+func TestProjection(t *testing.T) {
+	z, c := complex(1, 2), complex(3, 4)
+	tests := []struct {
+		p    Projection
+		want complex128
+	}{
+		{Projection{{1, 0, 0, 0}, {0, 1, 0, 0}}, Zrzi(z, c)},
+		{Projection{{0, 0, 1, 0}, {0, 1, 0, 0}}, Crzi(z, c)},
+		{Projection{{0.5, 0, 0.5, 0}, {0, 0, 0, -1}}, complex(2, -4)},
+	}
+	for _, test := range tests {
+		if got := test.p.Plane()(z, c); got != test.want {
+			t.Errorf("%v: expected %v, got %v", test.p, test.want, got)
+		}
+	}
+
+	// Each capital plane is the projection onto two of the axes.
+	named := map[string]Projection{
+		"zrzi": {{1, 0, 0, 0}, {0, 1, 0, 0}},
+		"zrcr": {{1, 0, 0, 0}, {0, 0, 1, 0}},
+		"zrci": {{1, 0, 0, 0}, {0, 0, 0, 1}},
+		"zicr": {{0, 1, 0, 0}, {0, 0, 1, 0}},
+		"zici": {{0, 1, 0, 0}, {0, 0, 0, 1}},
+		"crci": {{0, 0, 1, 0}, {0, 0, 0, 1}},
+		"crzi": {{0, 0, 1, 0}, {0, 1, 0, 0}},
+	}
+	if len(named) != len(planes) {
+		t.Errorf("expected %d named planes, got %d", len(named), len(planes))
+	}
+	points := [][2]complex128{{z, c}, {complex(-0.5, 0.25), complex(-2, 0.75)}}
+	for name, p := range named {
+		plane, ok := NamedPlane(name)
+		if !ok {
+			t.Errorf("plane %q not found", name)
+			continue
+		}
+		for _, pt := range points {
+			if got, want := plane(pt[0], pt[1]), p.Plane()(pt[0], pt[1]); got != want {
+				t.Errorf("%s%v: expected %v, got %v", name, pt, want, got)
+			}
+		}
+	}
+	if _, ok := NamedPlane("ZrZi"); !ok {
+		t.Errorf("plane names must be case insensitive")
+	}
+}
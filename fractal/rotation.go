@@ -0,0 +1,69 @@
+package fractal
+
+import "math"
+
+// Rotation is a rotation of the four dimensional space (Zr, Zi, Cr, Ci), given
+// by the angles in radians of the six planes of rotation. Rotating the ZrCr and
+// ZiCi planes by a quarter turn transitions the Zrzi plane into the Crci plane.
+type Rotation struct {
+	ZrZi, ZrCr, ZrCi, ZiCr, ZiCi, CrCi float64
+}
+
+// matrix is a 4x4 rotation matrix of the points (Zr, Zi, Cr, Ci).
+type matrix [4][4]float64
+
+// identity is the identity matrix.
+var identity = matrix{{1, 0, 0, 0}, {0, 1, 0, 0}, {0, 0, 1, 0}, {0, 0, 0, 1}}
+
+// matrix returns the rotation matrix, the product of the rotations of the six
+// planes.
+func (r Rotation) matrix() matrix {
+	m := identity
+	for _, plane := range []struct {
+		i, j  int
+		theta float64
+	}{
+		{0, 1, r.ZrZi},
+		{0, 2, r.ZrCr},
+		{0, 3, r.ZrCi},
+		{1, 2, r.ZiCr},
+		{1, 3, r.ZiCi},
+		{2, 3, r.CrCi},
+	} {
+		if plane.theta == 0 {
+			continue
+		}
+		// Multiply with the Givens rotation of the plane.
+		sin, cos := math.Sincos(plane.theta)
+		for k := range m {
+			mi, mj := m[k][plane.i], m[k][plane.j]
+			m[k][plane.i] = cos*mi + sin*mj
+			m[k][plane.j] = -sin*mi + cos*mj
+		}
+	}
+	return m
+}
+
+// apply rotates the point (z, c).
+func (m *matrix) apply(z, c complex128) (complex128, complex128) {
+	v := [4]float64{real(z), imag(z), real(c), imag(c)}
+	var w [4]float64
+	for i := range m {
+		w[i] = m[i][0]*v[0] + m[i][1]*v[1] + m[i][2]*v[2] + m[i][3]*v[3]
+	}
+	return complex(w[0], w[1]), complex(w[2], w[3])
+}
+
+// Rotation returns the rotation applied to the points before they are
+// projected onto the plane.
+func (frac *Fractal) Rotation() Rotation {
+	return frac.rotation
+}
+
+// Rotate sets the rotation applied to the points before they are projected
+// onto the plane.
+func (frac *Fractal) Rotate(r Rotation) {
+	frac.rotation = r
+	frac.matrix = r.matrix()
+	frac.rotated = r != Rotation{}
+}
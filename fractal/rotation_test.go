@@ -0,0 +1,43 @@
+package fractal
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+
+	rand7i "github.com/7i/rand"
+)
+
+func TestRotation(t *testing.T) {
+	// A quarter turn of the ZrCr and ZiCi planes rotates c into z.
+	m := Rotation{ZrCr: math.Pi / 2, ZiCi: math.Pi / 2}.matrix()
+	z, c := complex(1, 2), complex(3, 4)
+	gz, gc := m.apply(z, c)
+	if cmplx.Abs(gz+c) > 1e-12 || cmplx.Abs(gc-z) > 1e-12 {
+		t.Errorf("expected (%v, %v), got (%v, %v)", -c, z, gz, gc)
+	}
+
+	// Rotations preserve the length of the points.
+	rng := rand7i.NewComplexRNG(1)
+	for i := 0; i < 100; i++ {
+		a, b, d := rng.Complex128Go(), rng.Complex128Go(), rng.Complex128Go()
+		m := Rotation{real(a), imag(a), real(b), imag(b), real(d), imag(d)}.matrix()
+		z, c := rng.Complex128Go(), rng.Complex128Go()
+		gz, gc := m.apply(z, c)
+		if want, got := abs2(z)+abs2(c), abs2(gz)+abs2(gc); math.Abs(want-got) > 1e-12 {
+			t.Fatalf("rotation changed the squared length from %f to %f", want, got)
+		}
+	}
+}
+
+func TestComplexToImageAllocs(t *testing.T) {
+	frac := Config{Width: 8, Height: 8, Zoom: 1, Plane: Zrzi, Rotation: Rotation{ZrCr: 1, CrCi: 2}}.fractal()
+	if n := testing.AllocsPerRun(100, func() { frac.ComplexToImage(complex(0.1, 0.2), complex(0.3, 0.4)) }); n != 0 {
+		t.Errorf("expected no allocations, got %f", n)
+	}
+}
+
+// abs2 returns the squared length of z.
+func abs2(z complex128) float64 {
+	return real(z)*real(z) + imag(z)*imag(z)
+}
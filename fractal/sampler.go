@@ -0,0 +1,29 @@
+package fractal
+
+// Sampler determines the strategy used to choose the starting points of the
+// orbits.
+type Sampler int
+
+const (
+	// Uniform chooses every starting point independently with Z and C.
+	Uniform Sampler = iota
+	// Metropolis mutates previously accepted starting points and accepts them
+	// with the Metropolis-Hastings algorithm.
+	Metropolis
+	// Adaptive chooses starting points from a distribution given by the
+	// importance map of a first exploring pass.
+	Adaptive
+)
+
+func (s Sampler) String() string {
+	switch s {
+	case Uniform:
+		return "Uniform"
+	case Metropolis:
+		return "Metropolis"
+	case Adaptive:
+		return "Adaptive"
+	default:
+		return "fail"
+	}
+}
@@ -0,0 +1,239 @@
+package fractal
+
+import (
+	"math"
+	"math/bits"
+
+	rand7i "github.com/7i/rand"
+)
+
+// Source is a sequence of points from which the starting points of the orbits
+// are sampled.
+type Source interface {
+	// Point returns the next point of the sequence. The real and imaginary
+	// parts are in the range [-2, 2).
+	Point() complex128
+}
+
+// Sequence is a deterministic source, which the workers continue across the
+// rounds of a render and whose position is saved in checkpoints.
+type Sequence interface {
+	Source
+	// Index returns the number of points returned.
+	Index() uint64
+	// Seek continues the sequence after its first i points.
+	Seek(i uint64)
+}
+
+// The first of the two dimensions of the four dimensional sequences which the
+// points c and z are sampled from. The dimensions are disjoint, so that z and c
+// are sampled independently from the same kind of sequence.
+const (
+	DimC = 0
+	DimZ = 2
+)
+
+// Random is a source of pseudo-random points.
+type Random struct {
+	rng *rand7i.ComplexRNG
+}
+
+// NewRandom returns a source of pseudo-random points which shares the state of
+// the random number generator.
+func NewRandom(rng *rand7i.ComplexRNG) Source {
+	return &Random{rng: rng}
+}
+
+// Point returns the next pseudo-random point.
+func (r *Random) Point() complex128 {
+	return r.rng.Complex128Go()
+}
+
+// rotation is a Cranley-Patterson rotation of a low-discrepancy sequence in
+// the unit square. Each worker rotates its sequence by a random shift, which
+// makes the estimates of the workers independent but keeps the low
+// discrepancy of each sequence.
+type rotation struct {
+	x, y float64
+}
+
+// newRotation returns a random rotation.
+func newRotation(rng *rand7i.ComplexRNG) rotation {
+	p := rng.Complex128Go()
+	return rotation{x: (real(p) + 2) / 4, y: (imag(p) + 2) / 4}
+}
+
+// point rotates the point (u, v) of the unit square and scales it to the
+// sampling domain.
+func (r rotation) point(u, v float64) complex128 {
+	u, v = u+r.x, v+r.y
+	return complex(4*(u-math.Floor(u))-2, 4*(v-math.Floor(v))-2)
+}
+
+// haltonBases are the bases of the dimensions of the Halton sequence.
+var haltonBases = [4]uint64{2, 3, 5, 7}
+
+// Halton is a source of points from two dimensions of the four dimensional
+// Halton sequence with the bases 2, 3, 5 and 7.
+type Halton struct {
+	i   uint64
+	dim int // First of the two dimensions, DimC or DimZ.
+	rot rotation
+}
+
+// NewHalton returns the constructor of Halton sequences of the two dimensions
+// starting at dim, with a random rotation.
+func NewHalton(dim int) func(*rand7i.ComplexRNG) Source {
+	return func(rng *rand7i.ComplexRNG) Source {
+		return &Halton{dim: dim, rot: newRotation(rng)}
+	}
+}
+
+// Point returns the next point of the Halton sequence.
+func (h *Halton) Point() complex128 {
+	h.i++
+	return h.rot.point(radicalInverse(h.i, haltonBases[h.dim]), radicalInverse(h.i, haltonBases[h.dim+1]))
+}
+
+// Index returns the number of points returned.
+func (h *Halton) Index() uint64 { return h.i }
+
+// Seek continues the sequence after its first i points.
+func (h *Halton) Seek(i uint64) { h.i = i }
+
+// radicalInverse mirrors the digits of i in the given base around the decimal
+// point.
+func radicalInverse(i, base uint64) (r float64) {
+	f := 1 / float64(base)
+	for inv := f; i > 0; i /= base {
+		r += float64(i%base) * inv
+		inv *= f
+	}
+	return r
+}
+
+// r2Alphas are the inverse powers of the generalized golden ratio in four
+// dimensions, the root of x^5 = x + 1, in 64-bit fixed point.
+var r2Alphas = [4]uint64{
+	0xdb4f0b9175ae2165,
+	0xbbe0563303a4615f,
+	0xa0f2ec75a1fe1575,
+	0x89e182857d9ed688,
+}
+
+// R2 is a source of points from two dimensions of the additive recurrence with
+// the generalized golden ratio in four dimensions, by Martin Roberts. The
+// recurrence is computed in fixed point, which keeps its precision for any
+// number of points.
+type R2 struct {
+	i   uint64
+	dim int // First of the two dimensions, DimC or DimZ.
+	rot rotation
+}
+
+// NewR2 returns the constructor of R2 sequences of the two dimensions starting
+// at dim, with a random rotation.
+func NewR2(dim int) func(*rand7i.ComplexRNG) Source {
+	return func(rng *rand7i.ComplexRNG) Source {
+		return &R2{dim: dim, rot: newRotation(rng)}
+	}
+}
+
+// Point returns the next point of the R2 sequence.
+func (r *R2) Point() complex128 {
+	r.i++
+	// The products wrap around, which keeps their fractional parts.
+	x, y := r.i*r2Alphas[r.dim], r.i*r2Alphas[r.dim+1]
+	return r.rot.point(fixed(x), fixed(y))
+}
+
+// Index returns the number of points returned.
+func (r *R2) Index() uint64 { return r.i }
+
+// Seek continues the sequence after its first i points.
+func (r *R2) Seek(i uint64) { r.i = i }
+
+// fixed returns the 64-bit fixed point fraction x as a float in [0, 1).
+func fixed(x uint64) float64 {
+	// Keep the 53 bits of the float, so that rounding stays below one.
+	return float64(x>>11) / (1 << 53)
+}
+
+// sobolPolynomials are the degrees and the inner coefficients of the primitive
+// polynomials of the dimensions of the Sobol sequence after the first, with
+// their initial direction numbers, from the table of Joe and Kuo.
+var sobolPolynomials = [3]struct {
+	degree, coefs uint
+	m             []uint64
+}{
+	{1, 0, []uint64{1}},       // x + 1
+	{2, 1, []uint64{1, 3}},    // x^2 + x + 1
+	{3, 1, []uint64{1, 3, 1}}, // x^3 + x + 1
+}
+
+// sobolDirections are the direction numbers of the dimensions of the four
+// dimensional Sobol sequence. The first dimension is the van der Corput
+// sequence in base 2.
+var sobolDirections = func() (v [4][64]uint64) {
+	for k := range v[0] {
+		v[0][k] = 1 << uint(63-k)
+	}
+	for d, p := range sobolPolynomials {
+		dirs := &v[d+1]
+		for k, m := range p.m {
+			dirs[k] = m << uint(63-k)
+		}
+		for k := p.degree; k < 64; k++ {
+			dirs[k] = dirs[k-p.degree] ^ dirs[k-p.degree]>>p.degree
+			for j := uint(1); j < p.degree; j++ {
+				if p.coefs>>(p.degree-1-j)&1 == 1 {
+					dirs[k] ^= dirs[k-j]
+				}
+			}
+		}
+	}
+	return v
+}()
+
+// Sobol is a source of points from two dimensions of the four dimensional
+// Sobol sequence.
+type Sobol struct {
+	i    uint64
+	x, y uint64
+	dim  int // First of the two dimensions, DimC or DimZ.
+	rot  rotation
+}
+
+// NewSobol returns the constructor of Sobol sequences of the two dimensions
+// starting at dim, with a random rotation.
+func NewSobol(dim int) func(*rand7i.ComplexRNG) Source {
+	return func(rng *rand7i.ComplexRNG) Source {
+		return &Sobol{dim: dim, rot: newRotation(rng)}
+	}
+}
+
+// Point returns the next point of the Sobol sequence, generated in Gray code
+// order.
+func (s *Sobol) Point() complex128 {
+	// Index of the rightmost zero bit of the index.
+	c := bits.TrailingZeros64(^s.i)
+	s.i++
+	s.x ^= sobolDirections[s.dim][c]
+	s.y ^= sobolDirections[s.dim+1][c]
+	return s.rot.point(fixed(s.x), fixed(s.y))
+}
+
+// Index returns the number of points returned.
+func (s *Sobol) Index() uint64 { return s.i }
+
+// Seek continues the sequence after its first i points.
+func (s *Sobol) Seek(i uint64) {
+	s.i, s.x, s.y = i, 0, 0
+	// The point of the index is given by the bits of its Gray code.
+	for g, k := i^i>>1, 0; g != 0; g, k = g>>1, k+1 {
+		if g&1 == 1 {
+			s.x ^= sobolDirections[s.dim][k]
+			s.y ^= sobolDirections[s.dim+1][k]
+		}
+	}
+}
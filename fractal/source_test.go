@@ -0,0 +1,105 @@
+package fractal
+
+import (
+	"math"
+	"testing"
+
+	rand7i "github.com/7i/rand"
+)
+
+func TestSobol(t *testing.T) {
+	// The first points of the Sobol sequence, without rotation.
+	tests := []struct {
+		dim  int
+		want []complex128
+	}{
+		{DimC, []complex128{
+			complex(0.5, 0.5),
+			complex(0.75, 0.25),
+			complex(0.25, 0.75),
+			complex(0.375, 0.375),
+			complex(0.875, 0.875),
+		}},
+		{DimZ, []complex128{
+			complex(0.5, 0.5),
+			complex(0.25, 0.25),
+			complex(0.75, 0.75),
+			complex(0.625, 0.875),
+			complex(0.125, 0.375),
+		}},
+	}
+	for _, test := range tests {
+		s := &Sobol{dim: test.dim}
+		for i, w := range test.want {
+			if got := s.Point(); got != 4*w-complex(2, 2) {
+				t.Errorf("dimension %d, point %d: expected %v, got %v", test.dim, i, 4*w-complex(2, 2), got)
+			}
+		}
+	}
+}
+
+// sequences returns a sequence of each kind of the dimension.
+func sequences(rng *rand7i.ComplexRNG, dim int) []Sequence {
+	return []Sequence{
+		NewHalton(dim)(rng).(Sequence),
+		NewR2(dim)(rng).(Sequence),
+		NewSobol(dim)(rng).(Sequence),
+	}
+}
+
+func TestSourceDomain(t *testing.T) {
+	rng := rand7i.NewComplexRNG(1)
+	srcs := []Source{NewRandom(&rng)}
+	for _, seq := range append(sequences(&rng, DimC), sequences(&rng, DimZ)...) {
+		srcs = append(srcs, seq)
+	}
+	for _, src := range srcs {
+		for i := 0; i < 1e4; i++ {
+			p := src.Point()
+			if real(p) < -2 || real(p) >= 2 || imag(p) < -2 || imag(p) >= 2 {
+				t.Fatalf("%T: point %v outside of the sampling domain", src, p)
+			}
+		}
+	}
+}
+
+func TestSequenceSeek(t *testing.T) {
+	rng := rand7i.NewComplexRNG(1)
+	for _, seq := range sequences(&rng, DimZ) {
+		var want complex128
+		for i := 0; i < 1000; i++ {
+			want = seq.Point()
+		}
+		seq.Seek(999)
+		if got := seq.Point(); got != want || seq.Index() != 1000 {
+			t.Errorf("%T: expected point %v at index 1000, got %v at index %d", seq, want, got, seq.Index())
+		}
+		// Long renders continue past 2^32 points.
+		seq.Seek(1<<32 - 1)
+		seq.Point()
+		seq.Point()
+	}
+}
+
+func TestSequenceDimensions(t *testing.T) {
+	rng := rand7i.NewComplexRNG(1)
+	cs, zs := sequences(&rng, DimC), sequences(&rng, DimZ)
+	for i := range cs {
+		// The workers alternate between the points c and z. Each quadrant of
+		// (Re c, Re z) is sampled equally often, so c covers its whole range
+		// independently of z.
+		const n = 1 << 14
+		var quadrants [2][2]int
+		for j := 0; j < n; j++ {
+			c, z := cs[i].Point(), zs[i].Point()
+			quadrants[int(real(c)+2)/2][int(real(z)+2)/2]++
+		}
+		for _, q := range quadrants {
+			for _, m := range q {
+				if math.Abs(float64(m)/n-0.25) > 0.01 {
+					t.Errorf("%T: expected a quarter of the points in each quadrant, got %v", cs[i], quadrants)
+				}
+			}
+		}
+	}
+}
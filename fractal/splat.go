@@ -0,0 +1,124 @@
+package fractal
+
+import (
+	"errors"
+	"math"
+)
+
+// Kernel is the filter which distributes the points of the orbits to the cells
+// of the histograms.
+type Kernel int
+
+const (
+	// Nearest registers the whole weight of a point in the cell it falls in.
+	Nearest Kernel = iota
+	// Bilinear distributes the weight of a point between the four nearest
+	// cells by the distances to their centers.
+	Bilinear
+	// Tent distributes the weight of a point to the cells within the radius,
+	// falling off linearly with the distance.
+	Tent
+	// Gaussian distributes the weight of a point with a gaussian of the radius
+	// as standard deviation, cut off at three deviations.
+	Gaussian
+)
+
+func (k Kernel) String() string {
+	switch k {
+	case Nearest:
+		return "Nearest"
+	case Bilinear:
+		return "Bilinear"
+	case Tent:
+		return "Tent"
+	case Gaussian:
+		return "Gaussian"
+	default:
+		return "fail"
+	}
+}
+
+// MaxTaps is the largest number of cells along an axis which a point is
+// distributed to.
+const MaxTaps = 32
+
+// Splat distributes the weight of the points of the orbits across the
+// neighbouring cells of the histograms, which anti-aliases renders of few
+// orbits. The kernels are separable, the weight of a cell is the product of the
+// weights along the axes.
+type Splat struct {
+	Kernel Kernel
+	Radius float64 // Radius in cells of the tent, and standard deviation of the gaussian.
+}
+
+// validate returns an error if the kernel is unknown or its radius is out of
+// range.
+func (s *Splat) validate() error {
+	switch s.Kernel {
+	case Nearest, Bilinear:
+		return nil
+	case Tent, Gaussian:
+		if s.Radius <= 0 {
+			return errors.New("splat radius must be positive")
+		}
+		if 2*math.Ceil(s.support())+1 > MaxTaps {
+			return errors.New("splat radius is too large")
+		}
+		return nil
+	}
+	return errors.New("invalid splat kernel")
+}
+
+// support returns the distance from the point beyond which the kernel is zero.
+func (s *Splat) support() float64 {
+	switch s.Kernel {
+	case Bilinear:
+		return 1
+	case Tent:
+		return s.Radius
+	case Gaussian:
+		return 3 * s.Radius
+	}
+	return 0
+}
+
+// weight returns the weight of the kernel at the distance d from the point.
+func (s *Splat) weight(d float64) float64 {
+	switch s.Kernel {
+	case Bilinear:
+		return math.Max(0, 1-d)
+	case Tent:
+		return math.Max(0, 1-d/s.Radius)
+	case Gaussian:
+		return math.Exp(-d * d / (2 * s.Radius * s.Radius))
+	}
+	return 1
+}
+
+// Taps returns the first of the n consecutive cells along an axis which the
+// point at the continuous coordinate x is distributed to, and saves their
+// weights, which sum to one.
+func (s *Splat) Taps(x float64, weights *[MaxTaps]float64) (first, n int) {
+	if s.Kernel != Nearest {
+		// The cells whose centers are within the support.
+		r := s.support()
+		first = int(math.Ceil(x - 0.5 - r))
+		last := int(math.Floor(x - 0.5 + r))
+		var sum float64
+		for i := first; i <= last && n < MaxTaps; i++ {
+			weights[n] = s.weight(math.Abs(float64(i) + 0.5 - x))
+			sum += weights[n]
+			n++
+		}
+		if sum > 0 {
+			for i := range weights[:n] {
+				weights[i] /= sum
+			}
+			return first, n
+		}
+	}
+	// The cell of the point, also for kernels narrower than the distance to
+	// the nearest center.
+	weights[0] = 1
+	return int(math.Floor(x)), 1
+}
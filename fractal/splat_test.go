@@ -0,0 +1,50 @@
+package fractal
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSplatTaps(t *testing.T) {
+	var weights [MaxTaps]float64
+	tests := []struct {
+		splat   Splat
+		x       float64
+		first   int
+		weights []float64
+	}{
+		{Splat{Kernel: Nearest}, 2.75, 2, []float64{1}},
+		// Bilinear weighs the two nearest centers by their distances.
+		{Splat{Kernel: Bilinear}, 2.75, 2, []float64{0.75, 0.25}},
+		{Splat{Kernel: Bilinear}, 2.5, 1, []float64{0, 1, 0}},
+		{Splat{Kernel: Tent, Radius: 2}, 2.5, 0, []float64{0, 0.25, 0.5, 0.25, 0}},
+		// Kernels narrower than the distance to the nearest center fall back
+		// to the cell of the point.
+		{Splat{Kernel: Tent, Radius: 0.1}, 2.1, 2, []float64{1}},
+	}
+	for _, test := range tests {
+		first, n := test.splat.Taps(test.x, &weights)
+		if first != test.first || n != len(test.weights) {
+			t.Errorf("%v at %g: expected %d cells from %d, got %d from %d", test.splat.Kernel, test.x, len(test.weights), test.first, n, first)
+			continue
+		}
+		for i, w := range test.weights {
+			if math.Abs(weights[i]-w) > 1e-12 {
+				t.Errorf("%v at %g: expected weights %v, got %v", test.splat.Kernel, test.x, test.weights, weights[:n])
+				break
+			}
+		}
+	}
+
+	// The weights of the gaussian sum to one and are centered on the point.
+	s := Splat{Kernel: Gaussian, Radius: 1.5}
+	first, n := s.Taps(7.3, &weights)
+	var sum, mean float64
+	for i, w := range weights[:n] {
+		sum += w
+		mean += w * (float64(first+i) + 0.5)
+	}
+	if math.Abs(sum-1) > 1e-12 || math.Abs(mean-7.3) > 1e-2 {
+		t.Errorf("gaussian: expected sum 1 and mean 7.3, got %g and %g", sum, mean)
+	}
+}
@@ -3,28 +3,114 @@ package histo
 
 import (
 	"encoding/gob"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 )
 
-// Histo is a histogram of buddhabrot orbits.
-type Histo [][]float64
+// Version is the version of the format of saved histograms, which is saved
+// before the histograms.
+const Version = 1
+
+// ErrVersion is the error of loading histograms saved in another format, such
+// as the slices of columns which preceded the flat histograms.
+var ErrVersion = errors.New("histograms saved in an older format, re-render them")
+
+// Histo is a histogram of buddhabrot orbits. The cells are stored row by row
+// in a flat slice, like the pixels of image.RGBA, so the cell (x, y) is the
+// cell (x, y) of the image.
+type Histo struct {
+	Pix           []float64 // The cells, where (x, y) is at Pix[y*Stride+x].
+	Stride        int       // Distance in Pix between vertically adjacent cells.
+	Width, Height int       // Dimensions of the histogram.
+}
 
 // New creates a histogram for an image of width * height.
 func New(width, height int) Histo {
-	var h = make(Histo, width)
-	for i := range h {
-		h[i] = make([]float64, height)
+	return Histo{
+		Pix:    make([]float64, width*height),
+		Stride: width,
+		Width:  width,
+		Height: height,
+	}
+}
+
+// Index returns the index in Pix of the cell (x, y).
+func (h Histo) Index(x, y int) int {
+	return y*h.Stride + x
+}
+
+// At returns the value of the cell (x, y).
+func (h Histo) At(x, y int) float64 {
+	return h.Pix[y*h.Stride+x]
+}
+
+// Add adds v to the cell (x, y).
+func (h Histo) Add(x, y int, v float64) {
+	h.Pix[y*h.Stride+x] += v
+}
+
+// Reset sets the cells to zero.
+func (h Histo) Reset() {
+	if cells := h.cells(); cells != nil {
+		for i := range cells {
+			cells[i] = 0
+		}
+		return
+	}
+	for y := 0; y < h.Height; y++ {
+		row := h.Row(y)
+		for x := range row {
+			row[x] = 0
+		}
+	}
+}
+
+// Sub returns the histogram of the width x height cells at (x, y), which
+// shares its cells with h.
+func (h Histo) Sub(x, y, width, height int) Histo {
+	if width <= 0 || height <= 0 {
+		return Histo{Stride: h.Stride}
+	}
+	i := h.Index(x, y)
+	return Histo{
+		Pix:    h.Pix[i : i+(height-1)*h.Stride+width],
+		Stride: h.Stride,
+		Width:  width,
+		Height: height,
+	}
+}
+
+// Row returns the cells of row y.
+func (h Histo) Row(y int) []float64 {
+	i := y * h.Stride
+	return h.Pix[i : i+h.Width]
+}
+
+// cells returns the cells of the histogram, or nil if the rows aren't
+// contiguous.
+func (h Histo) cells() []float64 {
+	if h.Stride != h.Width {
+		return nil
 	}
-	return h
+	return h.Pix[:h.Width*h.Height]
 }
 
 // Max finds the highest value in the histogram. Used for color scaling
 // algorithms.
-func Max(v Histo) (max float64) {
+func Max(h Histo) (max float64) {
 	max = -1
-	for _, row := range v {
-		for _, v := range row {
+	if cells := h.cells(); cells != nil {
+		for _, v := range cells {
+			if v > max {
+				max = v
+			}
+		}
+		return max
+	}
+	for y := 0; y < h.Height; y++ {
+		for _, v := range h.Row(y) {
 			if v > max {
 				max = v
 			}
@@ -41,6 +127,9 @@ func Save(vs ...Histo) (err error) {
 	}
 	defer file.Close()
 	enc := gob.NewEncoder(file)
+	if err := enc.Encode(Version); err != nil {
+		return err
+	}
 	for _, v := range vs {
 		err = enc.Encode(v)
 		if err != nil {
@@ -54,30 +143,71 @@ func Save(vs ...Histo) (err error) {
 func Load() (r, g, b Histo, err error) {
 	file, err := os.Open("r-g-b.gob")
 	if err != nil {
-		return nil, nil, nil, err
+		return Histo{}, Histo{}, Histo{}, err
 	}
 	defer file.Close()
 	dec := gob.NewDecoder(file)
+	if err := CheckVersion(dec, Version); err != nil {
+		return Histo{}, Histo{}, Histo{}, err
+	}
 	if err := dec.Decode(&r); err != nil {
-		return nil, nil, nil, err
+		return Histo{}, Histo{}, Histo{}, err
 	}
 	if err := dec.Decode(&g); err != nil {
-		return nil, nil, nil, err
+		return Histo{}, Histo{}, Histo{}, err
 	}
 	if err := dec.Decode(&b); err != nil {
-		return nil, nil, nil, err
+		return Histo{}, Histo{}, Histo{}, err
 	}
 	return r, g, b, nil
 }
 
+// CheckVersion decodes the version of the format of a gob file, and returns
+// ErrVersion unless it's version. The files of older formats start with other
+// types than the version, which fail to decode.
+func CheckVersion(dec *gob.Decoder, version int) error {
+	var v int
+	if err := dec.Decode(&v); err == io.EOF {
+		return err
+	} else if err != nil || v != version {
+		return ErrVersion
+	}
+	return nil
+}
+
+// Merge adds the histogram a to b and returns b.
 func Merge(a, b Histo) (Histo, error) {
-	if len(a) != len(b) || len(a[0]) != len(b[0]) {
-		return nil, fmt.Errorf("invalid sizes of histograms: %dx%d != %dx%d", len(a), len(a[0]), len(b), len(b[0]))
+	if a.Width != b.Width || a.Height != b.Height {
+		return Histo{}, fmt.Errorf("invalid sizes of histograms: %dx%d != %dx%d", a.Width, a.Height, b.Width, b.Height)
+	}
+	if src, dst := a.cells(), b.cells(); src != nil && dst != nil {
+		dst = dst[:len(src)]
+		for i, v := range src {
+			dst[i] += v
+		}
+		return b, nil
 	}
-	for y, row := range a {
-		for x, cell := range row {
-			b[y][x] += cell
+	for y := 0; y < a.Height; y++ {
+		dst := b.Row(y)
+		for x, v := range a.Row(y) {
+			dst[x] += v
 		}
 	}
 	return b, nil
 }
+
+// Downsample returns the histogram of the sums of the blocks of factor x factor
+// cells, which is the histogram of a render without supersampling.
+func Downsample(h Histo, factor int) Histo {
+	if factor <= 1 {
+		return h
+	}
+	d := New(h.Width/factor, h.Height/factor)
+	for y := 0; y < h.Height; y++ {
+		dst := d.Row(y / factor)
+		for x, v := range h.Row(y) {
+			dst[x/factor] += v
+		}
+	}
+	return d
+}
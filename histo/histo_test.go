@@ -0,0 +1,208 @@
+package histo
+
+import (
+	"encoding/gob"
+	"errors"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestDownsample(t *testing.T) {
+	h := New(4, 2)
+	for i := range h.Pix {
+		h.Pix[i] = float64(i)
+	}
+	d := Downsample(h, 2)
+	if d.Width != 2 || d.Height != 1 {
+		t.Fatalf("expected 2x1 cells, got %dx%d", d.Width, d.Height)
+	}
+	// The blocks are {0, 1, 4, 5} and {2, 3, 6, 7}.
+	if d.At(0, 0) != 10 || d.At(1, 0) != 18 {
+		t.Errorf("expected [10 18], got %v", d.Pix)
+	}
+	if _, err := Merge(h, d); err == nil {
+		t.Error("expected error merging histograms of different sizes")
+	}
+}
+
+func TestReset(t *testing.T) {
+	h := New(3, 2)
+	h.Add(2, 1, 4)
+	h.Reset()
+	if Max(h) != 0 {
+		t.Errorf("expected empty cells, got %v", h.Pix)
+	}
+}
+
+func TestSub(t *testing.T) {
+	h := New(4, 3)
+	for i := range h.Pix {
+		h.Pix[i] = float64(i)
+	}
+	// The rows of the view aren't contiguous.
+	s := h.Sub(1, 1, 2, 2)
+	if s.At(0, 0) != 5 || s.At(1, 1) != 10 || Max(s) != 10 {
+		t.Errorf("expected the cells [5 6 9 10], got %v", s.Pix)
+	}
+	d := New(2, 2)
+	d.Add(1, 0, 1)
+	if _, err := Merge(d, s); err != nil {
+		t.Fatal(err)
+	}
+	if h.At(2, 1) != 7 || h.At(3, 1) != 7 {
+		t.Errorf("expected the merged cell to be shared, got %v", h.Pix)
+	}
+	s.Reset()
+	if h.At(1, 1) != 0 || h.At(2, 2) != 0 || h.At(3, 1) != 7 || h.At(0, 2) != 8 {
+		t.Errorf("expected only the cells of the view to be reset, got %v", h.Pix)
+	}
+	if d := Downsample(h.Sub(0, 0, 4, 2), 2); d.At(0, 0) != 5 || d.At(1, 0) != 12 {
+		t.Errorf("expected [5 12], got %v", d.Pix)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "histo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	r, g, b := New(2, 1), New(2, 1), New(2, 1)
+	r.Add(0, 0, 1)
+	g.Add(1, 0, 2)
+	if err := Save(r, g, b); err != nil {
+		t.Fatal(err)
+	}
+	lr, lg, lb, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual([]Histo{lr, lg, lb}, []Histo{r, g, b}) {
+		t.Errorf("loaded histograms differ from the saved histograms")
+	}
+
+	// Histograms saved as slices of columns.
+	file, err := os.Create("r-g-b.gob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := gob.NewEncoder(file).Encode(newNested(2, 1)); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	if _, _, _, err := Load(); !errors.Is(err, ErrVersion) {
+		t.Errorf("expected an error of an older format, got %v", err)
+	}
+}
+
+const (
+	benchWidth  = 1024
+	benchHeight = 1024
+)
+
+// nested is the former layout of the histograms, a slice of columns, which is
+// the baseline of the benchmarks.
+type nested [][]float64
+
+func newNested(width, height int) nested {
+	h := make(nested, width)
+	for x := range h {
+		h[x] = make([]float64, height)
+	}
+	return h
+}
+
+// cells returns random cells in the dimensions of the benchmarks.
+func cells(n int) [][2]int {
+	rng := rand.New(rand.NewSource(1))
+	cs := make([][2]int, n)
+	for i := range cs {
+		cs[i] = [2]int{rng.Intn(benchWidth), rng.Intn(benchHeight)}
+	}
+	return cs
+}
+
+func BenchmarkAdd(b *testing.B) {
+	h, cs := New(benchWidth, benchHeight), cells(1<<16)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c := cs[i&(len(cs)-1)]
+		h.Add(c[0], c[1], 1)
+	}
+}
+
+func BenchmarkAddNested(b *testing.B) {
+	h, cs := newNested(benchWidth, benchHeight), cells(1<<16)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c := cs[i&(len(cs)-1)]
+		h[c[0]][c[1]]++
+	}
+}
+
+func BenchmarkMax(b *testing.B) {
+	h := New(benchWidth, benchHeight)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Max(h)
+	}
+}
+
+func BenchmarkMaxNested(b *testing.B) {
+	h := newNested(benchWidth, benchHeight)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		max := -1.0
+		for _, col := range h {
+			for _, v := range col {
+				if v > max {
+					max = v
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkMerge(b *testing.B) {
+	x, y := New(benchWidth, benchHeight), New(benchWidth, benchHeight)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Merge(x, y)
+	}
+}
+
+func BenchmarkMergeNested(b *testing.B) {
+	x, y := newNested(benchWidth, benchHeight), newNested(benchWidth, benchHeight)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for c, col := range x {
+			for r, v := range col {
+				y[c][r] += v
+			}
+		}
+	}
+}
+
+func BenchmarkNew(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		New(benchWidth, benchHeight)
+	}
+}
+
+func BenchmarkNewNested(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		newNested(benchWidth, benchHeight)
+	}
+}
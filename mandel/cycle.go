@@ -0,0 +1,38 @@
+package mandel
+
+// Cycle detects periodic orbits with Brent's algorithm. The orbit is compared
+// to a saved point which is moved forward at powers of two, which finds cycles
+// of any period in at most twice the iterations needed to reach the cycle. Two
+// points are considered equal if their distance is within epsilon, which
+// catches orbits converging towards an attracting cycle.
+//
+// For formulas with memory the state of the orbit is both the point and the
+// previous point, and both must repeat.
+type Cycle struct {
+	eps       float64    // Squared tolerance of the distance between points.
+	saved     complex128 // Saved point of the orbit, the tortoise.
+	savedPrev complex128 // Point preceding the saved point.
+	power     int64      // Number of iterations until the saved point is moved.
+	distance  int64      // Number of iterations since the saved point was moved.
+}
+
+// NewCycle returns a cycle detector for the orbit starting at z, preceded by
+// prev.
+func NewCycle(z, prev complex128, eps float64) Cycle {
+	return Cycle{eps: eps * eps, saved: z, savedPrev: prev, power: 1, distance: 1}
+}
+
+// Period returns the period of the cycle if the next point z of the orbit,
+// preceded by prev, closes a cycle, and zero otherwise.
+func (cy *Cycle) Period(z, prev complex128) int64 {
+	if abs(z-cy.saved) <= cy.eps && abs(prev-cy.savedPrev) <= cy.eps {
+		return cy.distance
+	}
+	if cy.power == cy.distance {
+		cy.saved, cy.savedPrev = z, prev
+		cy.power *= 2
+		cy.distance = 0
+	}
+	cy.distance++
+	return 0
+}
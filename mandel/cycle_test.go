@@ -0,0 +1,31 @@
+package mandel
+
+import "testing"
+
+func TestCyclePeriod(t *testing.T) {
+	tests := []struct {
+		c      complex128
+		eps    float64
+		period int64
+	}{
+		// Fixed point of the main cardioid.
+		{c: 0, period: 1},
+		// Superattracting cycles of the period-2 and period-3 bulbs.
+		{c: -1, period: 2},
+		{c: complex(-0.12256116687665, 0.74486176661974), eps: 1e-9, period: 3},
+		// Attracting period-2 cycle which is only reached approximately.
+		{c: complex(-1.1, 0.1), eps: 1e-9, period: 2},
+	}
+	for _, test := range tests {
+		var z complex128
+		cy := NewCycle(z, 0, test.eps)
+		var period int64
+		for i := 0; i < 1e5 && period == 0; i++ {
+			z = z*z + test.c
+			period = cy.Period(z, 0)
+		}
+		if period != test.period {
+			t.Errorf("%v: expected period %d, got %d", test.c, test.period, period)
+		}
+	}
+}
@@ -9,17 +9,18 @@ func FieldLines(z, c complex128, orbit *fractal.Orbit, frac *fractal.Fractal) in
 	g := 10000.0
 	// We ignore all values that we know are in the bulb, and will therefore
 	// converge.
-	if IsInBulb(c) {
+	if inBulb(z, c, frac) {
 		return -1
 	}
 
 	// Saved value for cycle-detection.
 	var bfract complex128
+	it := frac.Iterate(z)
 
 	// See if the complex function diverges before we reach our iteration count.
 	var i int64
 	for i = 0; i < frac.Iterations; i++ {
-		z = frac.Func(z, c, frac.Coef)
+		z = it.Next(z, c)
 		if IsCycle(z, &bfract, i) {
 			return -1
 		}
@@ -44,11 +45,12 @@ func FieldLinesEscapes(z, c complex128, frac *fractal.Fractal, g float64) (compl
 
 	// Saved value for cycle-detection.
 	var bfract complex128
+	it := frac.Iterate(z)
 
 	// See if the complex function diverges before we reach our iteration count.
 	var i int64
 	for i = 0; i < frac.Iterations; i++ {
-		z = frac.Func(z, c, frac.Coef)
+		z = it.Next(z, c)
 		if IsCycle(z, &bfract, i) {
 			return z, -1
 		}
@@ -0,0 +1,220 @@
+package mandel
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/cmplx"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/karlek/wasabi/fractal"
+	"github.com/karlek/wasabi/prec"
+)
+
+// Formula is an escape-time formula of the catalogue, with the options it is
+// best explored with.
+type Formula struct {
+	Name        string                                              // Name of the formula, in lower case.
+	Description string                                              // Iteration of the formula.
+	Func        func(complex128, complex128, complex128) complex128 // The complex function.
+	Memory      func(z, prev, c, coef complex128) complex128        // The complex function of formulas which depend on the previous point, instead of Func.
+	Prec        func(z, c, coef prec.Complex) prec.Complex          // The complex function in precision tiers beyond float64, nil if the formula only supports float64.
+	Perturb     Perturbation                                        // Perturbation of the formula around a reference orbit, nil if the formula has none.
+	Bailout     float64                                             // Recommended (squared) bailout radius.
+	Domain      fractal.Domain                                      // Default sampling domain of c, nil samples the rectangle [-2, 2).
+	Critical    complex128                                          // Critical point which z should start from.
+	Roots       []complex128                                        // Roots which the orbits of root-finding formulas converge to.
+
+	// Parametric returns the function for the parameter given after the name
+	// as name:param, e.g. multibrot:2.5. It is nil for formulas without a
+	// parameter.
+	Parametric func(float64) func(complex128, complex128, complex128) complex128
+
+	// Polynomial returns the formula of the given polynomial for root-finding
+	// formulas. It is nil for other formulas.
+	Polynomial func(Polynomial) Formula
+}
+
+// ErrUnknownFormula is returned by LookupFormula for names not in the
+// catalogue.
+var ErrUnknownFormula = errors.New("unknown formula")
+
+// formulas is the catalogue of formulas by name.
+var formulas = make(map[string]Formula)
+
+// AddFormula adds the formula to the catalogue, replacing any formula of the
+// same name.
+func AddFormula(f Formula) {
+	f.Name = strings.ToLower(f.Name)
+	formulas[f.Name] = f
+}
+
+// LookupFormula returns the formula of the given name. The parameter of a
+// parametric formula is given after a colon, e.g. multibrot:2.5.
+func LookupFormula(name string) (Formula, error) {
+	name = strings.ToLower(name)
+	var param string
+	if i := strings.IndexByte(name, ':'); i != -1 {
+		name, param = name[:i], name[i+1:]
+	}
+	f, ok := formulas[name]
+	if !ok {
+		return f, fmt.Errorf("%w %q", ErrUnknownFormula, name)
+	}
+	if param == "" {
+		return f, nil
+	}
+	if f.Parametric == nil {
+		return f, fmt.Errorf("formula %q has no parameter", name)
+	}
+	p, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return f, fmt.Errorf("invalid parameter of formula %q: %v", name, err)
+	}
+	f.Func = f.Parametric(p)
+	return f, nil
+}
+
+// Formulas returns the formulas of the catalogue sorted by name.
+func Formulas() []Formula {
+	fs := make([]Formula, 0, len(formulas))
+	for _, f := range formulas {
+		fs = append(fs, f)
+	}
+	sort.Slice(fs, func(i, j int) bool { return fs[i].Name < fs[j].Name })
+	return fs
+}
+
+// disc is the default sampling domain, which contains the escape region of
+// most formulas.
+var disc = fractal.Disc{Radius: 2}
+
+func init() {
+	for _, f := range []Formula{
+		{Name: "mandelbrot", Description: "coef*z^2 + coef*c", Func: Mandelbrot, Prec: MandelbrotPrec, Perturb: PerturbMandelbrot, Bailout: 4},
+		{Name: "burningship", Description: "(|Re z| + i|Im z|)^2 + c", Func: BurningShip, Prec: BurningShipPrec, Perturb: PerturbBurningShip, Bailout: 4},
+		{Name: "b1", Description: "conj(z^2 + c)", Func: B1, Bailout: 4},
+		{Name: "b2", Description: "Im(w) - Re(w) + i Re(w) Im(w), w = z^2 + c", Func: B2, Bailout: 4},
+		{Name: "monk", Description: "cot(c) atanh(z) + c", Func: Monk, Bailout: 4},
+		{Name: "wrench", Description: "|Im z Im c Re z| + i|Im z Re z Re c| + c", Func: Wrench, Bailout: 4},
+		{
+			Name:        "multibrot",
+			Description: "z^d + c, with the real power d given as multibrot:d (default 3)",
+			Func:        Multibrot(3),
+			Parametric:  Multibrot,
+			Bailout:     4,
+			Domain:      disc,
+		},
+		{Name: "tricorn", Description: "conj(z)^2 + c", Func: Tricorn, Prec: TricornPrec, Bailout: 4, Domain: disc},
+		{Name: "celtic", Description: "|Re z^2| + i Im z^2 + c", Func: Celtic, Bailout: 4, Domain: disc},
+		{Name: "perpendicular", Description: "(Re z - i|Im z|)^2 + c", Func: PerpendicularBurningShip, Bailout: 4, Domain: disc},
+		{Name: "buffalo", Description: "|Re z^2| + i|Im z^2| + c", Func: Buffalo, Bailout: 4, Domain: disc},
+		{Name: "heart", Description: "Re z^2 + 2i|Re z| Im z + c", Func: Heart, Bailout: 4, Domain: disc},
+		{
+			Name:        "magnet1",
+			Description: "((z^2 + c - 1) / (2z + c - 2))^2",
+			Func:        Magnet1,
+			Bailout:     100,
+			Domain:      fractal.Rectangle{Min: complex(-2, -3), Max: complex(4, 3)},
+		},
+		{
+			Name:        "magnet2",
+			Description: "((z^3 + 3(c-1)z + (c-1)(c-2)) / (3z^2 + 3(c-2)z + (c-1)(c-2) + 1))^2",
+			Func:        Magnet2,
+			Bailout:     100,
+			Domain:      fractal.Rectangle{Min: complex(-2, -3), Max: complex(4, 3)},
+		},
+		{
+			Name:        "phoenix",
+			Description: "z^2 + c + coef*prev, where prev is the previous point (e.g. coef -0.5)",
+			Memory:      Phoenix,
+			Bailout:     4,
+			Domain:      disc,
+		},
+		{
+			Name:        "manowar",
+			Description: "z^2 + prev + c, where prev is the previous point",
+			Memory:      Manowar,
+			Bailout:     4,
+			Domain:      disc,
+		},
+		{
+			Name:        "lambda",
+			Description: "c z (1 - z)",
+			Func:        Lambda,
+			Bailout:     16,
+			Domain:      fractal.Rectangle{Min: complex(-2, -2), Max: complex(4, 2)},
+			Critical:    0.5,
+		},
+		NewtonFormula(Polynomial{-1, 0, 0, 1}),
+		NovaFormula(Polynomial{-1, 0, 0, 1}),
+	} {
+		AddFormula(f)
+	}
+}
+
+// Multibrot returns the multibrot function z^d + c of the real power d.
+func Multibrot(d float64) func(z, c, _ complex128) complex128 {
+	if d == math.Trunc(d) && d >= 1 && d <= 64 {
+		n := int(d)
+		return func(z, c, _ complex128) complex128 {
+			r := z
+			for i := 1; i < n; i++ {
+				r *= z
+			}
+			return r + c
+		}
+	}
+	return func(z, c, _ complex128) complex128 {
+		return cmplx.Pow(z, complex(d, 0)) + c
+	}
+}
+
+func Tricorn(z, c, _ complex128) complex128 {
+	z = cmplx.Conj(z)
+	return z*z + c
+}
+
+func Celtic(z, c, _ complex128) complex128 {
+	z *= z
+	return complex(math.Abs(real(z)), imag(z)) + c
+}
+
+func PerpendicularBurningShip(z, c, _ complex128) complex128 {
+	z = complex(real(z), -math.Abs(imag(z)))
+	return z*z + c
+}
+
+func Buffalo(z, c, _ complex128) complex128 {
+	z *= z
+	return complex(math.Abs(real(z)), math.Abs(imag(z))) + c
+}
+
+func Heart(z, c, _ complex128) complex128 {
+	x, y := real(z), imag(z)
+	return complex(x*x-y*y, 2*math.Abs(x)*y) + c
+}
+
+func Magnet1(z, c, _ complex128) complex128 {
+	w := (z*z + c - 1) / (2*z + c - 2)
+	return w * w
+}
+
+func Magnet2(z, c, _ complex128) complex128 {
+	w := (z*z*z + 3*(c-1)*z + (c-1)*(c-2)) / (3*z*z + 3*(c-2)*z + (c-1)*(c-2) + 1)
+	return w * w
+}
+
+func Lambda(z, c, _ complex128) complex128 {
+	return c * z * (1 - z)
+}
+
+func Phoenix(z, prev, c, coef complex128) complex128 {
+	return z*z + c + coef*prev
+}
+
+func Manowar(z, prev, c, _ complex128) complex128 {
+	return z*z + prev + c
+}
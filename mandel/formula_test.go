@@ -0,0 +1,100 @@
+package mandel
+
+import (
+	"errors"
+	"math/cmplx"
+	"testing"
+
+	"github.com/karlek/wasabi/fractal"
+)
+
+func TestLookupFormula(t *testing.T) {
+	z, c := complex(0.3, -0.4), complex(-0.2, 0.7)
+	tests := []struct {
+		name string
+		want complex128
+	}{
+		{"mandelbrot", Mandelbrot(z, c, 1)},
+		{"Tricorn", cmplx.Conj(z)*cmplx.Conj(z) + c},
+		{"multibrot", z*z*z + c},
+		{"multibrot:4", z*z*z*z + c},
+		{"multibrot:2.5", cmplx.Pow(z, 2.5) + c},
+	}
+	for _, test := range tests {
+		f, err := LookupFormula(test.name)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if got := f.Func(z, c, 1); cmplx.Abs(got-test.want) > 1e-12 {
+			t.Errorf("%s: expected %v, got %v", test.name, test.want, got)
+		}
+	}
+
+	if _, err := LookupFormula("z^2 + c"); !errors.Is(err, ErrUnknownFormula) {
+		t.Errorf("expected unknown formula, got %v", err)
+	}
+	for _, name := range []string{"tricorn:2", "multibrot:x"} {
+		if _, err := LookupFormula(name); err == nil || errors.Is(err, ErrUnknownFormula) {
+			t.Errorf("%s: expected invalid parameter, got %v", name, err)
+		}
+	}
+	for _, f := range Formulas() {
+		if (f.Func == nil && f.Memory == nil) || f.Bailout <= 0 {
+			t.Errorf("%s: missing function or bailout", f.Name)
+		}
+	}
+}
+
+func TestMemory(t *testing.T) {
+	frac, err := fractal.FromConfig(fractal.Config{
+		Width:      8,
+		Height:     8,
+		Iterations: 500,
+		Bailout:    4,
+		Memory:     Phoenix,
+		Coef:       -0.5,
+		Register:   Escaped,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	orbit := fractal.NewOrbit(frac.Iterations)
+	for _, c := range []complex128{complex(0.4, 0.3), complex(-0.3, 0.9), complex(0.1, 0.1), complex(1, 1)} {
+		// The orbit of the recurrence, until it escapes.
+		var want []complex128
+		var z, prev complex128
+		for i := int64(0); i < frac.Iterations; i++ {
+			z, prev = z*z+c-0.5*prev, z
+			if abs(z) >= frac.Bailout {
+				break
+			}
+			want = append(want, z)
+		}
+		escaped := len(want) < int(frac.Iterations)
+
+		got := Escaped(0, c, orbit, frac)
+		if escaped && (got != int64(len(want)) || !equalPoints(orbit.Points[:got], want)) {
+			t.Errorf("%v: expected escaping orbit of length %d, got %d", c, len(want), got)
+		}
+		if !escaped && got != -1 {
+			t.Errorf("%v: expected bounded orbit, got length %d", c, got)
+		}
+		if got := Converged(0, c, orbit, frac); escaped != (got == -1) {
+			t.Errorf("%v: expected converged %v, got length %d", c, !escaped, got)
+		}
+		if got := Primitive(0, c, orbit, frac); escaped && got != int64(len(want)) {
+			t.Errorf("%v: expected primitive orbit of length %d, got %d", c, len(want), got)
+		}
+	}
+}
+
+// equalPoints returns true if the points are equal.
+func equalPoints(a, b []complex128) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return len(a) == len(b)
+}
@@ -6,6 +6,7 @@ import (
 	"reflect"
 
 	"github.com/karlek/wasabi/fractal"
+	"github.com/karlek/wasabi/prec"
 )
 
 // Registrer is a function which registers if the points (z, c) creates an orbit
@@ -37,6 +38,12 @@ func IsInBulb(c complex128) bool {
 	return true
 }
 
+// inBulb returns true if the fractal is the mandelbrot, the orbit starts at
+// origo and the point c is in one of its larger bulbs.
+func inBulb(z, c complex128, frac *fractal.Fractal) bool {
+	return z == 0 && frac.Coef == 1 && equal(frac.Func, Mandelbrot) && IsInBulb(c)
+}
+
 // IsCycle uses exponential back-off for cycle detection.
 func IsCycle(z complex128, bfract *complex128, i int64) bool {
 	// Cycle-detection (See algorithmic explanation in README.md).
@@ -66,25 +73,35 @@ func abs(c complex128) float64 {
 // iterations) we discard the orbit.
 func Escaped(z, c complex128, orbit *fractal.Orbit, frac *fractal.Fractal) int64 {
 	// We ignore all values that we know are in the bulb, and will therefore
-	// converge.
-	if IsInBulb(c) {
+	// converge, and those the pre-pass knows won't register an orbit.
+	if inBulb(z, c, frac) || frac.Grid.Cell(c) != fractal.Unknown {
+		return -1
+	}
+	if frac.Precision != prec.Float64 {
+		if i, end := iteratePrec(z, c, orbit, frac); end == escaped {
+			return i
+		}
 		return -1
 	}
 
-	// Saved value for cycle-detection.
-	var bfract complex128
+	orbit.Period = 0
+	// Iterator of the orbit and cycle-detection of orbits converging to
+	// periodic points.
+	it := frac.Iterate(z)
+	cycle := NewCycle(z, it.Prev(), frac.Epsilon)
 
 	// See if the complex function diverges before we reach our iteration count.
 	var i int64
 	for i = 0; i < frac.Iterations; i++ {
-		z = frac.Func(z, c, frac.Coef)
-		if IsCycle(z, &bfract, i) {
+		z = it.Next(z, c)
+		if orbit.Period = cycle.Period(z, it.Prev()); orbit.Period != 0 {
 			return -1
 		}
 
 		// This point diverges, which means all the preceeding points are interesting
 		// and will be registered.
 		if IsOutside(z, frac.Bailout) {
+			orbit.Last = z
 			return i
 		}
 		orbit.Points[i] = z
@@ -96,17 +113,27 @@ func Escaped(z, c complex128, orbit *fractal.Orbit, frac *fractal.Fractal) int64
 // Converged returns all points in the domain of the complex function before
 // diverging.
 func Converged(z, c complex128, orbit *fractal.Orbit, frac *fractal.Fractal) int64 {
-	if IsInBulb(c) {
+	if inBulb(z, c, frac) || frac.Grid.Cell(c) == fractal.Exterior {
+		return -1
+	}
+	if frac.Precision != prec.Float64 {
+		if i, end := iteratePrec(z, c, orbit, frac); end != escaped {
+			return i
+		}
 		return -1
 	}
-	// Saved value for cycle-detection.
-	var bfract complex128
+	orbit.Period = 0
+	// Iterator of the orbit and cycle-detection of orbits converging to
+	// periodic points.
+	it := frac.Iterate(z)
+	cycle := NewCycle(z, it.Prev(), frac.Epsilon)
 
 	// See if the complex function diverges before we reach our iteration count.
 	var i int64
 	for i = 0; i < frac.Iterations; i++ {
-		z = frac.Func(z, c, frac.Coef)
-		if IsCycle(z, &bfract, i) {
+		z = it.Next(z, c)
+		if orbit.Period = cycle.Period(z, it.Prev()); orbit.Period != 0 {
+			orbit.Last = z
 			return i
 		}
 
@@ -120,25 +147,35 @@ func Converged(z, c complex128, orbit *fractal.Orbit, frac *fractal.Fractal) int
 	}
 	// This point converges; assumed under the number of iterations. Since it's
 	// the anti-buddhabrot we register the orbit.
+	orbit.Last = z
 	return i
 }
 
 // Primitive returns all points in the domain of the complex function
 // diverging.
 func Primitive(z, c complex128, orbit *fractal.Orbit, frac *fractal.Fractal) (i int64) {
-	// Saved value for cycle-detection.
-	var bfract complex128
+	if frac.Precision != prec.Float64 {
+		i, _ = iteratePrec(z, c, orbit, frac)
+		return i
+	}
+	orbit.Period = 0
+	// Iterator of the orbit and cycle-detection of orbits converging to
+	// periodic points.
+	it := frac.Iterate(z)
+	cycle := NewCycle(z, it.Prev(), frac.Epsilon)
 
 	// See if the complex function diverges before we reach our iteration count.
 	for i = 0; i < frac.Iterations; i++ {
-		z = frac.Func(z, c, frac.Coef)
-		if IsCycle(z, &bfract, i) {
+		z = it.Next(z, c)
+		if orbit.Period = cycle.Period(z, it.Prev()); orbit.Period != 0 {
+			orbit.Last = z
 			return i
 		}
 
 		// This point diverges. Since it's the primitive brot we register the
 		// orbit.
 		if IsOutside(z, frac.Bailout) {
+			orbit.Last = z
 			return i
 		}
 		// Save the point.
@@ -146,6 +183,7 @@ func Primitive(z, c complex128, orbit *fractal.Orbit, frac *fractal.Fractal) (i
 	}
 	// This point converges; assumed under the number of iterations.
 	// Since it's the primitive brot we register the orbit.
+	orbit.Last = z
 	return i
 }
 
@@ -155,18 +193,20 @@ func Primitive(z, c complex128, orbit *fractal.Orbit, frac *fractal.Fractal) (i
 func EscapedLast(z, c complex128, frac *fractal.Fractal) (complex128, int64) {
 	// We ignore all values that we know are in the bulb, and will therefore
 	// converge.
-	if IsInBulb(c) && equal(frac.Func, Mandelbrot) {
+	if inBulb(z, c, frac) {
 		return z, -1
 	}
 
-	// Saved value for cycle-detection.
-	var bfract complex128
+	// Iterator of the orbit and cycle-detection of orbits converging to
+	// periodic points.
+	it := frac.Iterate(z)
+	cycle := NewCycle(z, it.Prev(), frac.Epsilon)
 
 	// See if the complex function diverges before we reach our iteration count.
 	var i int64
 	for i = 0; i < frac.Iterations; i++ {
-		z = frac.Func(z, c, frac.Coef)
-		if IsCycle(z, &bfract, i) {
+		z = it.Next(z, c)
+		if cycle.Period(z, it.Prev()) != 0 {
 			return z, -1
 		}
 
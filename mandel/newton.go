@@ -0,0 +1,196 @@
+package mandel
+
+import (
+	"math"
+	"math/cmplx"
+	"sort"
+
+	"github.com/karlek/wasabi/fractal"
+)
+
+// Polynomial is a complex polynomial with its coefficients in order of
+// increasing degree, e.g. {-1, 0, 0, 1} is z^3 - 1.
+type Polynomial []complex128
+
+// Eval returns the value of the polynomial at z.
+func (p Polynomial) Eval(z complex128) complex128 {
+	var v complex128
+	for i := len(p) - 1; i >= 0; i-- {
+		v = v*z + p[i]
+	}
+	return v
+}
+
+// Derivative returns the derivative of the polynomial.
+func (p Polynomial) Derivative() Polynomial {
+	if len(p) < 2 {
+		return Polynomial{0}
+	}
+	d := make(Polynomial, len(p)-1)
+	for i := range d {
+		d[i] = complex(float64(i+1), 0) * p[i+1]
+	}
+	return d
+}
+
+// Degree returns the degree of the polynomial, ignoring zero coefficients of
+// the highest degrees.
+func (p Polynomial) Degree() int {
+	n := len(p) - 1
+	for n > 0 && p[n] == 0 {
+		n--
+	}
+	return n
+}
+
+// Roots returns the roots of the polynomial found by the Durand-Kerner
+// method, sorted by their argument in [0, 2π).
+func (p Polynomial) Roots() []complex128 {
+	n := p.Degree()
+	if n < 1 {
+		return nil
+	}
+	roots := make([]complex128, n)
+	// The initial guesses are powers of a number which is neither real nor a
+	// root of unity.
+	for i := range roots {
+		roots[i] = cmplx.Pow(0.4+0.9i, complex(float64(i), 0))
+	}
+	for iter := 0; iter < 1000; iter++ {
+		var delta float64
+		for i, r := range roots {
+			den := p[n]
+			for j, s := range roots {
+				if j != i {
+					den *= r - s
+				}
+			}
+			d := p.Eval(r) / den
+			roots[i] -= d
+			delta = math.Max(delta, cmplx.Abs(d))
+		}
+		if delta < 1e-15 {
+			break
+		}
+	}
+	sort.Slice(roots, func(i, j int) bool { return angle(roots[i]) < angle(roots[j]) })
+	return roots
+}
+
+// angle returns the argument of z in [0, 2π).
+func angle(z complex128) float64 {
+	a := cmplx.Phase(z)
+	if a < 0 {
+		a += 2 * math.Pi
+	}
+	return a
+}
+
+// Newton returns the complex function of Newton's method for the roots of the
+// polynomial, relaxed by the coefficient: z - coef*p(z)/p'(z).
+func Newton(p Polynomial) func(z, c, coef complex128) complex128 {
+	dp := p.Derivative()
+	return func(z, _, coef complex128) complex128 {
+		return z - coef*p.Eval(z)/dp.Eval(z)
+	}
+}
+
+// Nova returns the complex function of the nova fractal of the polynomial,
+// which is Newton's method perturbed by c: z - coef*p(z)/p'(z) + c.
+func Nova(p Polynomial) func(z, c, coef complex128) complex128 {
+	dp := p.Derivative()
+	return func(z, c, coef complex128) complex128 {
+		return z - coef*p.Eval(z)/dp.Eval(z) + c
+	}
+}
+
+// NewtonFormula returns the newton formula of the polynomial. The orbits start
+// at sampled points z, while c is ignored.
+func NewtonFormula(p Polynomial) Formula {
+	return Formula{
+		Name:        "newton",
+		Description: "z - coef*p(z)/p'(z), where z is sampled and p is the polynomial (default z^3 - 1)",
+		Func:        Newton(p),
+		Bailout:     1e10,
+		Roots:       p.Roots(),
+		Polynomial:  NewtonFormula,
+	}
+}
+
+// NovaFormula returns the nova formula of the polynomial. The orbits start at
+// the first root of the polynomial, which is a critical point of Newton's
+// method.
+func NovaFormula(p Polynomial) Formula {
+	f := Formula{
+		Name:        "nova",
+		Description: "z - coef*p(z)/p'(z) + c, where p is the polynomial (default z^3 - 1)",
+		Func:        Nova(p),
+		Bailout:     1e10,
+		Roots:       p.Roots(),
+		Polynomial:  NovaFormula,
+	}
+	if len(f.Roots) > 0 {
+		f.Critical = f.Roots[0]
+	}
+	return f
+}
+
+// Tolerance of the distance between consecutive points of converging orbits,
+// used when the fractal has no epsilon.
+const tolerance = 1e-9
+
+// Convergent returns all points in the domain of the complex function before
+// converging to a root of a root-finding formula, such as newton or nova.
+//
+// An orbit which converges to a fixed point has the period 1 and is classified
+// by the index of the root of the fractal nearest to the fixed point. The
+// fixed points of newton are the roots, while those of nova move away from the
+// roots as c grows, which makes its classification approximate far from the
+// origin. An orbit which converges to an attracting cycle of a larger period
+// is classified after the roots, by the index len(frac.Roots). Orbits which
+// diverge, or don't converge under the iterations, are discarded.
+func Convergent(z, c complex128, orbit *fractal.Orbit, frac *fractal.Fractal) int64 {
+	orbit.Period = 0
+	orbit.Root = 0
+	eps := frac.Epsilon
+	if eps == 0 {
+		eps = tolerance
+	}
+	it := frac.Iterate(z)
+	cycle := NewCycle(z, it.Prev(), eps)
+
+	var i int64
+	for i = 0; i < frac.Iterations; i++ {
+		next := it.Next(z, c)
+		if IsOutside(next, frac.Bailout) || cmplx.IsNaN(next) {
+			return -1
+		}
+		orbit.Points[i] = next
+		if abs(next-z) < eps*eps {
+			orbit.Period = 1
+			orbit.Root = Nearest(next, frac.Roots)
+			orbit.Last = next
+			return i + 1
+		}
+		if orbit.Period = cycle.Period(next, it.Prev()); orbit.Period != 0 {
+			orbit.Root = len(frac.Roots)
+			orbit.Last = next
+			return i + 1
+		}
+		z = next
+	}
+	return -1
+}
+
+// Nearest returns the index of the root nearest to z, or zero if there are no
+// roots.
+func Nearest(z complex128, roots []complex128) int {
+	var nearest int
+	min := math.Inf(1)
+	for i, r := range roots {
+		if d := abs(z - r); d < min {
+			nearest, min = i, d
+		}
+	}
+	return nearest
+}
@@ -0,0 +1,107 @@
+package mandel
+
+import (
+	"math/cmplx"
+	"testing"
+
+	"github.com/karlek/wasabi/fractal"
+)
+
+func TestPolynomialRoots(t *testing.T) {
+	// The cube roots of unity, sorted by their argument.
+	want := []complex128{1, cmplx.Rect(1, 2*cmplx.Phase(-1)/3), cmplx.Rect(1, -2*cmplx.Phase(-1)/3)}
+	roots := Polynomial{-1, 0, 0, 1}.Roots()
+	if len(roots) != len(want) {
+		t.Fatalf("expected %d roots, got %v", len(want), roots)
+	}
+	for i := range want {
+		if cmplx.Abs(roots[i]-want[i]) > 1e-12 {
+			t.Errorf("root %d: expected %v, got %v", i, want[i], roots[i])
+		}
+	}
+
+	// (z - 2i)(z + 0.5) with complex coefficients and a trailing zero.
+	p := Polynomial{-1i, 0.5 - 2i, 1, 0}
+	for _, r := range p.Roots() {
+		if v := p.Eval(r); cmplx.Abs(v) > 1e-12 {
+			t.Errorf("p(%v) = %v, expected a root", r, v)
+		}
+	}
+}
+
+func TestConvergent(t *testing.T) {
+	formula, err := LookupFormula("newton")
+	if err != nil {
+		t.Fatal(err)
+	}
+	frac, err := fractal.FromConfig(fractal.Config{
+		Width:      8,
+		Height:     8,
+		Iterations: 100,
+		Bailout:    formula.Bailout,
+		Func:       formula.Func,
+		Coef:       1,
+		Register:   Convergent,
+		Roots:      formula.Roots,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	orbit := fractal.NewOrbit(frac.Iterations)
+	for _, test := range []struct {
+		z    complex128
+		root int
+	}{
+		{2, 0},
+		{-1 + 1i, 1},
+		{-1 - 1i, 2},
+	} {
+		n := Convergent(test.z, 0, orbit, frac)
+		if n <= 0 {
+			t.Errorf("%v: expected the orbit to converge", test.z)
+			continue
+		}
+		if orbit.Root != test.root || orbit.Period != 1 {
+			t.Errorf("%v: expected the fixed point of root %d, got root %d of period %d", test.z, test.root, orbit.Root, orbit.Period)
+		}
+		if last := orbit.Points[n-1]; cmplx.Abs(last-formula.Roots[test.root]) > 1e-9 {
+			t.Errorf("%v: expected the orbit to end at %v, got %v", test.z, formula.Roots[test.root], last)
+		}
+	}
+	// The derivative is zero at the origin, so the orbit diverges.
+	if n := Convergent(0, 0, orbit, frac); n != -1 {
+		t.Errorf("expected the orbit of the origin to be discarded, got %d", n)
+	}
+}
+
+func TestConvergentCycle(t *testing.T) {
+	formula, err := LookupFormula("newton")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Newton's method of z^3 - 2z + 2 has the attracting cycle 0, 1.
+	formula = formula.Polynomial(Polynomial{2, -2, 0, 1})
+	frac, err := fractal.FromConfig(fractal.Config{
+		Width:      8,
+		Height:     8,
+		Iterations: 100,
+		Bailout:    formula.Bailout,
+		Func:       formula.Func,
+		Coef:       1,
+		Register:   Convergent,
+		Roots:      formula.Roots,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	orbit := fractal.NewOrbit(frac.Iterations)
+	for _, z := range []complex128{0, 0.01} {
+		if n := Convergent(z, 0, orbit, frac); n <= 0 {
+			t.Errorf("%v: expected the orbit to converge to the cycle", z)
+			continue
+		}
+		if orbit.Period != 2 || orbit.Root != len(frac.Roots) {
+			t.Errorf("%v: expected the cycle of period 2 after the roots, got root %d of period %d", z, orbit.Root, orbit.Period)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package mandel
+
+import (
+	"math/cmplx"
+
+	"github.com/karlek/wasabi/fractal"
+	"github.com/karlek/wasabi/prec"
+)
+
+// Perturbation returns the next delta of an orbit from its delta dz to the
+// point Z of a reference orbit, where dc is the delta between the points c of
+// the orbits and coef is the coefficient of the complex function. The deltas
+// are small enough for float64 even when the points themselves need a
+// precision tier.
+type Perturbation func(Z, dz, dc, coef complex128) complex128
+
+// PerturbMandelbrot is the perturbation of Mandelbrot.
+//
+//	coef((Z + dz)^2 + c + dc) - coef(Z^2 + c) = coef((2Z + dz)dz + dc)
+func PerturbMandelbrot(Z, dz, dc, coef complex128) complex128 {
+	return coef * ((2*Z+dz)*dz + dc)
+}
+
+// PerturbBurningShip is the perturbation of BurningShip, which has no
+// coefficient.
+func PerturbBurningShip(Z, dz, dc, _ complex128) complex128 {
+	X, Y := real(Z), imag(Z)
+	x, y := real(dz), imag(dz)
+	return complex(
+		(2*X+x)*x-(2*Y+y)*y,
+		2*diffabs(X*Y, X*y+x*Y+x*y)) + dc
+}
+
+// diffabs returns |c + d| - |c| without the cancellation of subtracting two
+// nearly equal numbers.
+func diffabs(c, d float64) float64 {
+	if c >= 0 {
+		if c+d >= 0 {
+			return d
+		}
+		return -(2*c + d)
+	}
+	if c+d > 0 {
+		return 2*c + d
+	}
+	return -d
+}
+
+// Reference is an orbit iterated in a precision tier, which the orbits of
+// nearby points are perturbations of. A single reference orbit at the center
+// of a deep zoom lets the orbits of every pixel be iterated as deltas in
+// float64.
+type Reference struct {
+	C     prec.Complex // The point c of the reference orbit.
+	Orbit []complex128 // The points of the orbit, starting at zero, rounded to complex128.
+
+	perturb Perturbation
+	coef    complex128
+
+	// Series approximation of the delta of the first skipped iterations as
+	// a cubic polynomial of dc.
+	skip   int
+	series [3]complex128
+}
+
+// NewReference iterates the reference orbit of c with the complex function of
+// the precision tier of the fractal, until it escapes or the iterations end.
+func NewReference(c prec.Complex, perturb Perturbation, frac *fractal.Fractal) *Reference {
+	ref := &Reference{C: c, Orbit: []complex128{0}, perturb: perturb, coef: frac.Coef}
+	// Start at a zero of the precision of c.
+	z := c.Sub(c)
+	coef := prec.New(c.Tier(), frac.Coef, frac.Bits)
+	for i := int64(0); i < frac.Iterations; i++ {
+		z = frac.Prec(z, c, coef)
+		p := z.Complex128()
+		ref.Orbit = append(ref.Orbit, p)
+		if IsOutside(p, frac.Bailout) {
+			break
+		}
+	}
+	return ref
+}
+
+// Tolerance of the cubic term of the series approximation relative to the
+// quadratic term.
+const seriesTolerance = 1e-6
+
+// Approximate skips the first iterations of the orbits of deltas within the
+// radius of the reference, by approximating their deltas with a cubic series in
+// dc. The series is only valid for PerturbMandelbrot, and returns the number
+// of skipped iterations.
+func (ref *Reference) Approximate(radius float64) int {
+	// The coefficients of dz = a dc + b dc^2 + c dc^3.
+	var a, b, c complex128
+	ref.skip = 0
+	for n := 0; n < len(ref.Orbit)-2; n++ {
+		Z, k := ref.Orbit[n], ref.coef
+		na := k * (2*Z*a + 1)
+		nb := k * (2*Z*b + a*a)
+		nc := k * (2*Z*c + 2*a*b)
+		// The approximation is valid while the cubic term is negligible.
+		if cmplx.IsInf(na) || cmplx.IsNaN(nc) || cmplx.Abs(nc)*radius > seriesTolerance*cmplx.Abs(nb) {
+			break
+		}
+		a, b, c = na, nb, nc
+		ref.skip = n + 1
+	}
+	ref.series = [3]complex128{a, b, c}
+	return ref.skip
+}
+
+// EscapedLast is EscapedLast for the point of the delta dc to the reference
+// point c.
+//
+// The orbit is rebased to the start of the reference orbit when it comes
+// closer to zero than its delta, which is when the delta has lost its
+// precision and would glitch, or when the reference orbit has escaped.
+func (ref *Reference) EscapedLast(dc complex128, frac *fractal.Fractal) (complex128, int64) {
+	var dz complex128
+	var n int
+	var i int64
+	if ref.skip > 0 {
+		dz = ((ref.series[2]*dc+ref.series[1])*dc + ref.series[0]) * dc
+		n, i = ref.skip, int64(ref.skip)
+	}
+	for ; i < frac.Iterations; i++ {
+		dz = ref.perturb(ref.Orbit[n], dz, dc, ref.coef)
+		n++
+		z := ref.Orbit[n] + dz
+		if IsOutside(z, frac.Bailout) {
+			return z, i
+		}
+		if abs(z) < abs(dz) || n == len(ref.Orbit)-1 {
+			dz, n = z, 0
+		}
+	}
+	return ref.Orbit[n] + dz, -1
+}
@@ -0,0 +1,87 @@
+package mandel
+
+import (
+	"math"
+	"testing"
+
+	"github.com/karlek/wasabi/fractal"
+	"github.com/karlek/wasabi/prec"
+)
+
+func TestReference(t *testing.T) {
+	const seahorse = "-0.743643887037158704752191506114774"
+	// The set of the coefficient k is the mandelbrot set scaled by 1/k^2.
+	scaled := [2]string{"-0.674506927017831024718541048630180", "0.119569981138604961898532477446838"}
+	tests := []struct {
+		name    string
+		formula string
+		center  [2]string
+		radius  float64
+		series  bool
+		coef    complex128
+	}{
+		{"mandelbrot", "mandelbrot", [2]string{seahorse, "0.131825904205311970493132056385139"}, 1e-14, false, 1},
+		{"series", "mandelbrot", [2]string{seahorse, "0.131825904205311970493132056385139"}, 1e-14, true, 1},
+		{"coef", "mandelbrot", scaled, 1e-14, false, 1.05},
+		{"coef series", "mandelbrot", scaled, 1e-14, true, 1.05},
+		{"burningship", "burningship", [2]string{"-0.4238692237431678", "0.07613077625683218"}, 1e-10, false, 1},
+	}
+	const steps = 5
+	for _, test := range tests {
+		formula, err := LookupFormula(test.formula)
+		if err != nil {
+			t.Fatal(err)
+		}
+		frac, err := fractal.FromConfig(fractal.Config{
+			Width:      8,
+			Height:     8,
+			Iterations: 5000,
+			Bailout:    4,
+			Func:       formula.Func,
+			Coef:       test.coef,
+			Prec:       formula.Prec,
+			Register:   Escaped,
+			Precision:  prec.DoubleDouble,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		center, err := prec.Parse(prec.DoubleDouble, test.center[0], test.center[1], 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ref := NewReference(center, formula.Perturb, frac)
+		if test.series && ref.Approximate(test.radius) == 0 {
+			t.Errorf("%s: expected the series approximation to skip iterations", test.name)
+		}
+		var escaped int
+		for i := -steps; i <= steps; i++ {
+			for j := -steps; j <= steps; j++ {
+				dc := complex(float64(i), float64(j)) * complex(test.radius/steps, 0)
+				c := center.Add(prec.New(prec.DoubleDouble, dc, 0))
+				_, want := EscapedLastPrec(prec.New(prec.DoubleDouble, 0, 0), c, frac)
+				_, got := ref.EscapedLast(dc, frac)
+				if got != want {
+					t.Errorf("%s: dc = %v: expected escape in %d, got %d", test.name, dc, want, got)
+				}
+				if got != -1 {
+					escaped++
+				}
+			}
+		}
+		if escaped == 0 {
+			t.Errorf("%s: expected some orbits to escape", test.name)
+		}
+	}
+}
+
+func TestDiffabs(t *testing.T) {
+	for _, c := range []float64{-3, -0.5, 0, 0.5, 3} {
+		for _, d := range []float64{-4, -1, -0.25, 0, 0.25, 1, 4} {
+			want := math.Abs(c+d) - math.Abs(c)
+			if got := diffabs(c, d); got != want {
+				t.Errorf("diffabs(%v, %v): expected %v, got %v", c, d, want, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package mandel
+
+import (
+	"github.com/karlek/wasabi/fractal"
+	"github.com/karlek/wasabi/prec"
+)
+
+// Ends of the iteration of an orbit in a precision tier.
+const (
+	bounded = iota // The orbit didn't escape under the iterations.
+	escaped        // The orbit escaped the bailout.
+	cycled         // The orbit converged to a cycle.
+)
+
+// iteratePrec iterates the orbit of z and c in the precision tier of the
+// fractal and saves the points relative to the origin of the fractal. It
+// returns the number of saved points and how the iteration ended.
+func iteratePrec(z, c complex128, orbit *fractal.Orbit, frac *fractal.Fractal) (int64, int) {
+	t := frac.Precision
+	pz, pc := prec.New(t, z, frac.Bits), prec.New(t, c, frac.Bits)
+	coef := prec.New(t, frac.Coef, frac.Bits)
+
+	orbit.Period = 0
+	// The cycle-detection compares the points relative to the origin, which
+	// keep the precision of the tier close to the origin.
+	cycle := NewCycle(pz.Sub(frac.Origin).Complex128(), 0, frac.Epsilon)
+
+	var i int64
+	for i = 0; i < frac.Iterations; i++ {
+		pz = frac.Prec(pz, pc, coef)
+		d := pz.Sub(frac.Origin).Complex128()
+		if orbit.Period = cycle.Period(d, 0); orbit.Period != 0 {
+			orbit.Last = pz.Complex128()
+			return i, cycled
+		}
+		if last := pz.Complex128(); IsOutside(last, frac.Bailout) {
+			orbit.Last = last
+			return i, escaped
+		}
+		orbit.Points[i] = d
+	}
+	orbit.Last = pz.Complex128()
+	return i, bounded
+}
+
+// EscapedLastPrec is EscapedLast in the precision tier of the points z and c,
+// which are usually given by ImageToPrec for deep zooms.
+func EscapedLastPrec(z, c prec.Complex, frac *fractal.Fractal) (complex128, int64) {
+	if inBulb(z.Complex128(), c.Complex128(), frac) {
+		return z.Complex128(), -1
+	}
+	coef := prec.New(z.Tier(), frac.Coef, frac.Bits)
+	cycle := NewCycle(z.Sub(c).Complex128(), 0, frac.Epsilon)
+
+	var i int64
+	for i = 0; i < frac.Iterations; i++ {
+		z = frac.Prec(z, c, coef)
+		// Compare the points relative to c, which is close to the orbit of
+		// points of the deep zoom converging to a cycle.
+		if cycle.Period(z.Sub(c).Complex128(), 0) != 0 {
+			return z.Complex128(), -1
+		}
+		if last := z.Complex128(); IsOutside(last, frac.Bailout) {
+			return last, i
+		}
+	}
+	return z.Complex128(), -1
+}
+
+// MandelbrotPrec is Mandelbrot in precision tiers.
+func MandelbrotPrec(z, c, coef prec.Complex) prec.Complex {
+	return z.Mul(z).Add(c).Mul(coef)
+}
+
+// BurningShipPrec is BurningShip in precision tiers.
+func BurningShipPrec(z, c, _ prec.Complex) prec.Complex {
+	z = z.AbsParts()
+	return z.Mul(z).Add(c)
+}
+
+// TricornPrec is Tricorn in precision tiers.
+func TricornPrec(z, c, _ prec.Complex) prec.Complex {
+	z = z.Conj()
+	return z.Mul(z).Add(c)
+}
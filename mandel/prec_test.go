@@ -0,0 +1,51 @@
+package mandel
+
+import (
+	"math/cmplx"
+	"testing"
+
+	"github.com/karlek/wasabi/fractal"
+	"github.com/karlek/wasabi/prec"
+)
+
+func TestPrecisionTiers(t *testing.T) {
+	cs := []complex128{-0.75 + 0.1i, 0.26 + 0.0015i, -1.25 + 0.05i, 0.3 + 0.5i, -0.1 + 0.651i}
+	for _, tier := range []prec.Tier{prec.DoubleDouble, prec.Big} {
+		for _, register := range []Registrer{Escaped, Converged, Primitive} {
+			want, got := newPrecFractal(t, prec.Float64, register), newPrecFractal(t, tier, register)
+			a, b := fractal.NewOrbit(want.Iterations), fractal.NewOrbit(got.Iterations)
+			for _, c := range cs {
+				n, m := register(0, c, a, want), register(0, c, b, got)
+				if n != m {
+					t.Errorf("%v: c = %v: expected length %d, got %d", tier, c, n, m)
+					continue
+				}
+				for i := int64(0); i < n; i++ {
+					if cmplx.Abs(a.Points[i]-b.Points[i]) > 1e-9 {
+						t.Errorf("%v: c = %v: point %d: expected %v, got %v", tier, c, i, a.Points[i], b.Points[i])
+						break
+					}
+				}
+			}
+		}
+	}
+}
+
+// newPrecFractal returns a mandelbrot of the precision tier.
+func newPrecFractal(t *testing.T, tier prec.Tier, register Registrer) *fractal.Fractal {
+	frac, err := fractal.FromConfig(fractal.Config{
+		Width:      8,
+		Height:     8,
+		Iterations: 200,
+		Bailout:    4,
+		Func:       Mandelbrot,
+		Coef:       1,
+		Prec:       MandelbrotPrec,
+		Register:   register,
+		Precision:  tier,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return frac
+}
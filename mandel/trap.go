@@ -17,12 +17,13 @@ func OrbitTrap(z, c complex128, frac *fractal.Fractal, trap func(complex128) flo
 
 	// Saved value for cycle-detection.
 	var bfract complex128
+	it := frac.Iterate(z)
 
 	// See if the complex function diverges before we reach our iteration
 	// count.
 	var i int64
 	for i = 0; i < frac.Iterations; i++ {
-		z = frac.Func(z, c, frac.Coef)
+		z = it.Next(z, c)
 		// Calculate and maybe save the distance of our new point.
 		if newDist := trap(z); dist > newDist {
 			dist = newDist
@@ -19,52 +19,56 @@ func Importance(ren *render.Render, frac *fractal.Fractal) {
 	}
 
 	impMax := histo.Max(frac.Importance)
-	for x, col := range frac.Importance {
-		for y, v := range col {
-			if frac.Importance[x][y] == 0 {
+	for y := 0; y < frac.Importance.Height; y++ {
+		for x, v := range frac.Importance.Row(y) {
+			if v == 0 {
 				continue
 			}
 			c := uint8(fscale(v, impMax))
-			ren.Image.SetRGBA(y, x, color.RGBA{c, c, c, 255})
+			ren.Image.SetRGBA(x, y, color.RGBA{c, c, c, 255})
 		}
 	}
 }
 
 // Plot visualizes the histograms values as an image. It equalizes the
 // histograms with a color scaling function to emphazise hidden features.
+// Supersampled histograms are downsampled to the pixels of the image.
 func Plot(ren *render.Render, frac *fractal.Fractal) {
+	r := histo.Downsample(frac.R, frac.Supersampling)
+	g := histo.Downsample(frac.G, frac.Supersampling)
+	b := histo.Downsample(frac.B, frac.Supersampling)
 	// The highest number orbits passing through a point.
-	rMax, gMax, bMax := histo.Max(frac.R), histo.Max(frac.G), histo.Max(frac.B)
+	rMax, gMax, bMax := histo.Max(r), histo.Max(g), histo.Max(b)
 	// We iterate over every point in our histogram to color scale and plot
 	// them.
 	wg := new(sync.WaitGroup)
-	wg.Add(len(frac.R))
-	for x, col := range frac.R {
-		go plotCol(wg, x, col, ren, frac, rMax, bMax, gMax)
+	wg.Add(r.Height)
+	for y := 0; y < r.Height; y++ {
+		go plotRow(wg, y, r, g, b, ren, rMax, bMax, gMax)
 	}
 	wg.Wait()
 }
 
-// plotCol plots a column of pixels. The RGB-value of the pixel is based on the
+// plotRow plots a row of pixels. The RGB-value of the pixel is based on the
 // frequency in the histogram. Higher value equals brighter color.
-func plotCol(wg *sync.WaitGroup, x int, col []float64, ren *render.Render, frac *fractal.Fractal, rMax, bMax, gMax float64) {
-	for y := range col {
+func plotRow(wg *sync.WaitGroup, y int, r, g, b histo.Histo, ren *render.Render, rMax, bMax, gMax float64) {
+	rs, gs, bs := r.Row(y), g.Row(y), b.Row(y)
+	for x := range rs {
 		// We skip to plot the black points for faster rendering. A side
 		// effect is that rendering png images will have a transparent
 		// background.
-		if frac.R[x][y] == 0 &&
-			frac.G[x][y] == 0 &&
-			frac.B[x][y] == 0 {
+		if rs[x] == 0 &&
+			gs[x] == 0 &&
+			bs[x] == 0 {
 			continue
 		}
 
 		c := color.RGBA{
-			uint8(255 * value(ren.F, frac.R[x][y], rMax, ren.Factor, ren.Exposure)),
-			uint8(255 * value(ren.F, frac.G[x][y], gMax, ren.Factor, ren.Exposure)),
-			uint8(255 * value(ren.F, frac.B[x][y], bMax, ren.Factor, ren.Exposure)),
+			uint8(255 * value(ren.F, rs[x], rMax, ren.Factor, ren.Exposure)),
+			uint8(255 * value(ren.F, gs[x], gMax, ren.Factor, ren.Exposure)),
+			uint8(255 * value(ren.F, bs[x], bMax, ren.Factor, ren.Exposure)),
 			255}
-		// We flip x <=> y to rotate the image to an upright position.
-		ren.Image.SetRGBA(y, x, c)
+		ren.Image.SetRGBA(x, y, c)
 	}
 	wg.Done()
 }
@@ -0,0 +1,77 @@
+package plot
+
+import (
+	"image"
+	"math/rand"
+	"testing"
+
+	"github.com/karlek/wasabi/fractal"
+	"github.com/karlek/wasabi/render"
+)
+
+// TestOrientation checks that a buddhabrot orbit through the point lotus
+// colors a pixel with is plotted at that pixel.
+func TestOrientation(t *testing.T) {
+	frac, err := fractal.FromConfig(fractal.Config{
+		Width:      40,
+		Height:     30,
+		Iterations: 1,
+		Bailout:    4,
+		Func:       func(z, c, _ complex128) complex128 { return z*z + c },
+		Register:   func(_, _ complex128, _ *fractal.Orbit, _ *fractal.Fractal) int64 { return 0 },
+		Plane:      fractal.Crci,
+		Zoom:       1.5,
+		Offset:     complex(0.5, -0.25),
+		Roll:       0.3,
+		Aspect:     1.5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []image.Point{{0, 0}, {39, 0}, {0, 29}, {39, 29}, {7, 22}} {
+		frac.Clear()
+		// Lotus colors the pixel with the point at its center.
+		c := frac.ImageToComplex(want.X, want.Y)
+		p, ok := frac.Point(0, c)
+		if !ok {
+			t.Fatalf("%v: point %v outside the image", want, c)
+		}
+		frac.R.Add(p.X, p.Y, 1)
+		ren := render.New(frac.Width, frac.Height, Lin, 1, 1)
+		Plot(ren, frac)
+		var lit []image.Point
+		for y := 0; y < frac.Height; y++ {
+			for x := 0; x < frac.Width; x++ {
+				if ren.Image.RGBAAt(x, y).A != 0 {
+					lit = append(lit, image.Point{X: x, Y: y})
+				}
+			}
+		}
+		if len(lit) != 1 || lit[0] != want {
+			t.Errorf("expected the pixel %v, got %v", want, lit)
+		}
+	}
+}
+
+func BenchmarkPlot(b *testing.B) {
+	frac, err := fractal.FromConfig(fractal.Config{
+		Width:      1024,
+		Height:     1024,
+		Iterations: 1,
+		Bailout:    4,
+		Func:       func(z, c, _ complex128) complex128 { return z*z + c },
+		Register:   func(_, _ complex128, _ *fractal.Orbit, _ *fractal.Fractal) int64 { return 0 },
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	rng := rand.New(rand.NewSource(1))
+	for i := range frac.R.Pix {
+		frac.R.Pix[i], frac.G.Pix[i], frac.B.Pix[i] = rng.Float64(), rng.Float64(), rng.Float64()
+	}
+	ren := render.New(frac.Width, frac.Height, Exp, 1, 1)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Plot(ren, frac)
+	}
+}
@@ -0,0 +1,49 @@
+package prec
+
+import "math/big"
+
+// BigComplex is a complex number of the arbitrary precision tier. The results
+// of operations have the precision of the first operand.
+type BigComplex struct {
+	Re, Im *big.Float
+}
+
+// float returns a new float of the precision of a.
+func (a BigComplex) float() *big.Float {
+	return new(big.Float).SetPrec(a.Re.Prec())
+}
+
+func (a BigComplex) Add(b Complex) Complex {
+	o := b.(BigComplex)
+	return BigComplex{a.float().Add(a.Re, o.Re), a.float().Add(a.Im, o.Im)}
+}
+
+func (a BigComplex) Sub(b Complex) Complex {
+	o := b.(BigComplex)
+	return BigComplex{a.float().Sub(a.Re, o.Re), a.float().Sub(a.Im, o.Im)}
+}
+
+func (a BigComplex) Mul(b Complex) Complex {
+	o := b.(BigComplex)
+	re := a.float().Mul(a.Re, o.Re)
+	re.Sub(re, a.float().Mul(a.Im, o.Im))
+	im := a.float().Mul(a.Re, o.Im)
+	im.Add(im, a.float().Mul(a.Im, o.Re))
+	return BigComplex{re, im}
+}
+
+func (a BigComplex) Conj() Complex {
+	return BigComplex{a.Re, a.float().Neg(a.Im)}
+}
+
+func (a BigComplex) AbsParts() Complex {
+	return BigComplex{a.float().Abs(a.Re), a.float().Abs(a.Im)}
+}
+
+func (a BigComplex) Complex128() complex128 {
+	re, _ := a.Re.Float64()
+	im, _ := a.Im.Float64()
+	return complex(re, im)
+}
+
+func (a BigComplex) Tier() Tier { return Big }
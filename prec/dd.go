@@ -0,0 +1,126 @@
+package prec
+
+import "math/big"
+
+// DD is a double-double number, the unevaluated sum Hi + Lo where Lo is below
+// half a unit in the last place of Hi.
+//
+// The explicit float64 conversions of products keep the compiler from fusing
+// them into multiply-adds, which would break the error-free transformations.
+type DD struct {
+	Hi, Lo float64
+}
+
+// twoSum returns the sum of a and b and its rounding error.
+func twoSum(a, b float64) (s, e float64) {
+	s = a + b
+	bb := s - a
+	e = (a - (s - bb)) + (b - bb)
+	return s, e
+}
+
+// quickTwoSum returns the sum of a and b and its rounding error, where |a| >= |b|.
+func quickTwoSum(a, b float64) (s, e float64) {
+	s = a + b
+	e = b - (s - a)
+	return s, e
+}
+
+// split splits a into two halves of 26 bits each.
+func split(a float64) (hi, lo float64) {
+	const factor = 1<<27 + 1
+	t := float64(factor * a)
+	hi = t - (t - a)
+	lo = a - hi
+	return hi, lo
+}
+
+// twoProd returns the product of a and b and its rounding error.
+func twoProd(a, b float64) (p, e float64) {
+	p = float64(a * b)
+	ah, al := split(a)
+	bh, bl := split(b)
+	e = ((float64(ah*bh) - p) + float64(ah*bl) + float64(al*bh)) + float64(al*bl)
+	return p, e
+}
+
+// Add returns a + b.
+func (a DD) Add(b DD) DD {
+	s, e := twoSum(a.Hi, b.Hi)
+	t, f := twoSum(a.Lo, b.Lo)
+	e += t
+	s, e = quickTwoSum(s, e)
+	e += f
+	s, e = quickTwoSum(s, e)
+	return DD{s, e}
+}
+
+// Neg returns -a.
+func (a DD) Neg() DD {
+	return DD{-a.Hi, -a.Lo}
+}
+
+// Sub returns a - b.
+func (a DD) Sub(b DD) DD {
+	return a.Add(b.Neg())
+}
+
+// Mul returns a * b.
+func (a DD) Mul(b DD) DD {
+	p, e := twoProd(a.Hi, b.Hi)
+	e += float64(a.Hi*b.Lo) + float64(a.Lo*b.Hi)
+	p, e = quickTwoSum(p, e)
+	return DD{p, e}
+}
+
+// Abs returns the absolute value of a.
+func (a DD) Abs() DD {
+	if a.Hi < 0 {
+		return a.Neg()
+	}
+	return a
+}
+
+// Float64 returns a rounded to float64.
+func (a DD) Float64() float64 {
+	return a.Hi + a.Lo
+}
+
+// splitBig returns the double-double nearest to f.
+func splitBig(f *big.Float) DD {
+	hi, _ := f.Float64()
+	rest := new(big.Float).SetPrec(f.Prec()).Sub(f, big.NewFloat(hi))
+	lo, _ := rest.Float64()
+	return DD{hi, lo}
+}
+
+// DDComplex is a complex number of the double-double tier.
+type DDComplex struct {
+	Re, Im DD
+}
+
+func (a DDComplex) Add(b Complex) Complex {
+	o := b.(DDComplex)
+	return DDComplex{a.Re.Add(o.Re), a.Im.Add(o.Im)}
+}
+
+func (a DDComplex) Sub(b Complex) Complex {
+	o := b.(DDComplex)
+	return DDComplex{a.Re.Sub(o.Re), a.Im.Sub(o.Im)}
+}
+
+func (a DDComplex) Mul(b Complex) Complex {
+	o := b.(DDComplex)
+	return DDComplex{
+		a.Re.Mul(o.Re).Sub(a.Im.Mul(o.Im)),
+		a.Re.Mul(o.Im).Add(a.Im.Mul(o.Re)),
+	}
+}
+
+func (a DDComplex) Conj() Complex     { return DDComplex{a.Re, a.Im.Neg()} }
+func (a DDComplex) AbsParts() Complex { return DDComplex{a.Re.Abs(), a.Im.Abs()} }
+func (a DDComplex) Tier() Tier        { return DoubleDouble }
+
+func (a DDComplex) Complex128() complex128 {
+	return complex(a.Re.Float64(), a.Im.Float64())
+}
@@ -0,0 +1,156 @@
+// Package prec implements complex numbers of increasing precision, which lets
+// deep zooms iterate orbits beyond the precision of complex128.
+//
+// The tiers are float64, double-double, which represents a number as the
+// unevaluated sum of two float64 for about 32 significant digits, and
+// arbitrary precision math/big.Float. All tiers implement the Complex
+// interface, so an orbit is iterated the same way in every tier.
+package prec
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// Tier is the precision of complex numbers.
+type Tier int
+
+// Precision tiers.
+const (
+	Float64      Tier = iota // complex128.
+	DoubleDouble             // Two float64 per component, about 106 bits.
+	Big                      // math/big.Float of a given number of bits.
+)
+
+func (t Tier) String() string {
+	switch t {
+	case Float64:
+		return "float64"
+	case DoubleDouble:
+		return "double-double"
+	case Big:
+		return "big"
+	default:
+		return "fail"
+	}
+}
+
+// ParseTier parses the name of a precision tier: float64, double-double or big.
+// The empty string is float64.
+func ParseTier(s string) (Tier, error) {
+	switch strings.ToLower(s) {
+	case "", "float64":
+		return Float64, nil
+	case "double-double", "doubledouble", "dd":
+		return DoubleDouble, nil
+	case "big", "arbitrary":
+		return Big, nil
+	}
+	return Float64, fmt.Errorf("invalid precision %q", s)
+}
+
+// DefaultBits is the precision in bits of big numbers created without a
+// precision.
+const DefaultBits = 128
+
+// ZoomBits returns enough bits of the big precision for the pixels of a render
+// at the zoom level to be distinct: the 64 bits of the image and a bit per
+// doubling of the zoom.
+func ZoomBits(zoom float64) uint {
+	return 64 + uint(math.Max(0, math.Log2(zoom)))
+}
+
+// Complex is a complex number of a precision tier. The operations return new
+// numbers and leave their operands unchanged. The operands of an operation
+// must be of the same tier.
+type Complex interface {
+	Add(Complex) Complex
+	Sub(Complex) Complex
+	Mul(Complex) Complex
+	// Conj returns the complex conjugate.
+	Conj() Complex
+	// AbsParts returns the absolute values of the real and imaginary
+	// components, as used by the burning ship.
+	AbsParts() Complex
+	// Complex128 returns the number rounded to complex128.
+	Complex128() complex128
+	// Tier returns the precision tier of the number.
+	Tier() Tier
+}
+
+// New returns z as a complex number of the tier. The bits are the precision
+// of big numbers, zero gives DefaultBits.
+func New(t Tier, z complex128, bits uint) Complex {
+	switch t {
+	case DoubleDouble:
+		return DDComplex{Re: DD{Hi: real(z)}, Im: DD{Hi: imag(z)}}
+	case Big:
+		if bits == 0 {
+			bits = DefaultBits
+		}
+		return BigComplex{
+			Re: new(big.Float).SetPrec(bits).SetFloat64(real(z)),
+			Im: new(big.Float).SetPrec(bits).SetFloat64(imag(z)),
+		}
+	default:
+		return F64(z)
+	}
+}
+
+// Parse parses the decimal strings of the real and imaginary components to a
+// complex number of the tier, without rounding them to float64 first. Empty
+// strings are zero.
+func Parse(t Tier, re, im string, bits uint) (Complex, error) {
+	if bits == 0 {
+		bits = DefaultBits
+	}
+	// Parse with enough bits for double-doubles.
+	parseBits := bits
+	if t != Big {
+		parseBits = DefaultBits
+	}
+	var parts [2]*big.Float
+	for i, s := range []string{re, im} {
+		if s == "" {
+			s = "0"
+		}
+		f, _, err := big.ParseFloat(strings.TrimSpace(s), 10, parseBits, big.ToNearestEven)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q: %v", s, err)
+		}
+		parts[i] = f
+	}
+	switch t {
+	case DoubleDouble:
+		return DDComplex{Re: splitBig(parts[0]), Im: splitBig(parts[1])}, nil
+	case Big:
+		return BigComplex{Re: parts[0], Im: parts[1]}, nil
+	default:
+		r, _ := parts[0].Float64()
+		i, _ := parts[1].Float64()
+		return F64(complex(r, i)), nil
+	}
+}
+
+// F64 is a complex number of the float64 tier.
+type F64 complex128
+
+func (a F64) Add(b Complex) Complex { return a + b.(F64) }
+func (a F64) Sub(b Complex) Complex { return a - b.(F64) }
+func (a F64) Mul(b Complex) Complex { return a * b.(F64) }
+func (a F64) Conj() Complex         { return F64(complex(real(a), -imag(a))) }
+func (a F64) AbsParts() Complex {
+	return F64(complex(abs(real(a)), abs(imag(a))))
+}
+func (a F64) Complex128() complex128 { return complex128(a) }
+func (a F64) Tier() Tier             { return Float64 }
+
+// abs returns the absolute value of x.
+func abs(x float64) float64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
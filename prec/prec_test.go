@@ -0,0 +1,94 @@
+package prec
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+var tiers = []Tier{Float64, DoubleDouble, Big}
+
+func TestArithmetic(t *testing.T) {
+	a, b := complex(0.3, -1.25), complex(-2.5, 0.75)
+	for _, tier := range tiers {
+		x, y := New(tier, a, 0), New(tier, b, 0)
+		tests := []struct {
+			name      string
+			got, want complex128
+		}{
+			{"add", x.Add(y).Complex128(), a + b},
+			{"sub", x.Sub(y).Complex128(), a - b},
+			{"mul", x.Mul(y).Complex128(), a * b},
+			{"conj", x.Conj().Complex128(), cmplx.Conj(a)},
+			{"abs", x.AbsParts().Complex128(), complex(0.3, 1.25)},
+		}
+		for _, test := range tests {
+			if cmplx.Abs(test.got-test.want) > 1e-15 {
+				t.Errorf("%v %s: expected %v, got %v", tier, test.name, test.want, test.got)
+			}
+		}
+		if x.Tier() != tier {
+			t.Errorf("expected tier %v, got %v", tier, x.Tier())
+		}
+	}
+}
+
+func TestPrecision(t *testing.T) {
+	// Points a distance of 1e-20 apart, which float64 can't tell apart.
+	const re, im = "-0.743643887037158704752191506114774", "0.131825904205311970493132056385139"
+	for _, tier := range tiers {
+		a, err := Parse(tier, re, im, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b := a.Add(New(tier, 1e-20, 0))
+		d := b.Sub(a).Complex128()
+		if tier == Float64 {
+			if d != 0 {
+				t.Errorf("%v: expected the difference to vanish, got %v", tier, d)
+			}
+			continue
+		}
+		if math.Abs(real(d)-1e-20) > 1e-30 || imag(d) != 0 {
+			t.Errorf("%v: expected the difference 1e-20, got %v", tier, d)
+		}
+	}
+
+	// The square of 1 + 2^-60 is 1 + 2^-59 + 2^-120.
+	for _, tier := range []Tier{DoubleDouble, Big} {
+		x := New(tier, 1, 0).Add(New(tier, complex(math.Ldexp(1, -60), 0), 0))
+		if d := x.Mul(x).Sub(New(tier, 1, 0)).Complex128(); real(d) != math.Ldexp(1, -59) {
+			t.Errorf("%v: expected 2^-59, got %v", tier, d)
+		}
+	}
+}
+
+func TestParseTier(t *testing.T) {
+	for _, tier := range tiers {
+		if got, err := ParseTier(tier.String()); err != nil || got != tier {
+			t.Errorf("%v: expected the tier, got %v, %v", tier, got, err)
+		}
+	}
+	if _, err := ParseTier("quad"); err == nil {
+		t.Error("expected error for an unknown tier")
+	}
+	if _, err := Parse(DoubleDouble, "1.5x", "", 0); err == nil {
+		t.Error("expected error for an invalid number")
+	}
+}
+
+func TestZoomBits(t *testing.T) {
+	for _, test := range []struct {
+		zoom float64
+		want uint
+	}{
+		{0.5, 64},
+		{1, 64},
+		{1e15, 113},
+		{math.Ldexp(1, 200), 264},
+	} {
+		if got := ZoomBits(test.zoom); got != test.want {
+			t.Errorf("zoom %g: expected %d bits, got %d", test.zoom, test.want, got)
+		}
+	}
+}